@@ -0,0 +1,126 @@
+package main
+
+import (
+	"cse224/proj4/pkg/surfstore"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+// Arguments
+const ARG_COUNT int = 1
+
+// Usage strings
+const USAGE_STRING = "./run-admin.sh -d (-add blockStoreAddr[=weight] | -remove blockStoreAddr) host:port | ./run-admin.sh -d -scrubstatus blockStoreAddr"
+
+const ADDR_NAME = "host:port"
+const ADDR_USAGE = "IP address and port of the MetaStore (comma separated for a raft cluster)"
+
+// Exit codes
+const EX_USAGE int = 64
+
+func main() {
+	// Custom flag Usage message
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
+		flag.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "  -%s: %v\n", f.Name, f.Usage)
+		})
+		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
+	}
+
+	// Parse command-line arguments and flags
+	debug := flag.Bool("d", false, "Output log statements")
+	add := flag.String("add", "", "BlockStore to add to the ring, optionally with its weight")
+	remove := flag.String("remove", "", "BlockStore to remove from the ring")
+	scrubStatus := flag.String("scrubstatus", "", "BlockStore to show the scrubber progress and corrupted blocks of (no host:port needed)")
+	flag.Parse()
+
+	// Use tail arguments to hold non-flag arguments
+	args := flag.Args()
+
+	if *scrubStatus != "" {
+		if len(args) != 0 || *add != "" || *remove != "" {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	} else if len(args) != ARG_COUNT || (*add == "") == (*remove == "") {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	// Disable log outputs if debug flag is missing
+	if !(*debug) {
+		log.SetFlags(0)
+		log.SetOutput(ioutil.Discard)
+	}
+
+	if *scrubStatus != "" {
+		rpcClient := surfstore.NewSurfstoreRPCClient("", "", 0)
+		defer rpcClient.Close()
+		var st surfstore.ScrubStatus
+		if err := rpcClient.GetScrubStatus(*scrubStatus, &st); err != nil {
+			fmt.Println("Error During Getting Scrub Status: ", err)
+			os.Exit(1)
+		}
+		printScrubStatus(&st)
+		return
+	}
+
+	rpcClient := surfstore.NewSurfstoreRPCClient(args[0], "", 0)
+	defer rpcClient.Close()
+
+	var succ bool
+	if *add != "" {
+		addrs, weights, err := surfstore.ParseBlockStoreArgs([]string{*add})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(EX_USAGE)
+		}
+		if err := rpcClient.AddBlockStore(addrs[0], weights[addrs[0]], &succ); err != nil {
+			fmt.Println("Error During Adding BlockStore: ", err)
+			os.Exit(1)
+		}
+		fmt.Println("Added BlockStore", addrs[0])
+	} else {
+		if err := rpcClient.RemoveBlockStore(*remove, &succ); err != nil {
+			fmt.Println("Error During Removing BlockStore: ", err)
+			os.Exit(1)
+		}
+		fmt.Println("Removed BlockStore", *remove)
+	}
+}
+
+func printScrubStatus(st *surfstore.ScrubStatus) {
+	if !st.Enabled {
+		fmt.Println("Scrubbing is off")
+		return
+	}
+	if st.Running {
+		fmt.Printf("Scrubbing: %d of %d blocks checked (%d bytes), started %s\n", st.BlocksChecked, st.BlocksTotal, st.BytesChecked, unixTime(st.PassStarted))
+	} else {
+		fmt.Printf("Idle, next pass %s\n", unixTime(st.NextPass))
+	}
+	fmt.Printf("Finished passes: %d", st.Passes)
+	if st.Passes > 0 {
+		fmt.Printf(", the last one finished %s", unixTime(st.PassFinished))
+	}
+	fmt.Println()
+
+	fmt.Printf("Corrupted blocks: %d\n", len(st.CorruptBlocks))
+	for _, cb := range st.CorruptBlocks {
+		result := "repaired from " + cb.RepairedFrom
+		if !cb.Repaired {
+			result = "not repaired: " + cb.Error
+		}
+		fmt.Printf("  %s found %s, %s\n", cb.Hash, unixTime(cb.FoundAt), result)
+	}
+}
+
+func unixTime(seconds int64) string {
+	return time.Unix(seconds, 0).Format(time.RFC3339)
+}
@@ -7,18 +7,62 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 )
 
 // Arguments
 const ARG_COUNT int = 3
 
 // Usage strings
-const USAGE_STRING = "./run-client.sh -d host:port baseDir blockSize"
+const USAGE_STRING = "./run-client.sh -d -watch -poll interval -chunking fixed|cdc -compress none|gzip|zstd -encrypt -keyfile path -metatimeout d -blocktimeout d -streamtimeout d -retries n -backoff d -concurrency n -perserver n host:port baseDir blockSize"
 
 const DEBUG_NAME = "d"
 const DEBUG_USAGE = "Output log statements"
 
+const WATCH_NAME = "watch"
+const WATCH_USAGE = "Keep running and sync whenever the base directory or the server changes"
+
+const POLL_NAME = "poll"
+const POLL_USAGE = "How often the server is checked for changes in watch mode"
+
+const CHUNKING_NAME = "chunking"
+const CHUNKING_USAGE = "How files are split into blocks: fixed (blockSize bytes each) or cdc (content-defined, blockSize bytes on average)"
+
+const COMPRESS_NAME = "compress"
+const COMPRESS_USAGE = "How blocks are compressed before they are uploaded: none, gzip or zstd"
+
+const ENCRYPT_NAME = "encrypt"
+const ENCRYPT_USAGE = "Encrypt blocks and filenames end to end, with the key from -keyfile or the passphrase in $" + PASSPHRASE_ENV
+
+const KEYFILE_NAME = "keyfile"
+const KEYFILE_USAGE = "File holding the encryption key (at least 32 random bytes), used with -encrypt"
+
+const METATIMEOUT_NAME = "metatimeout"
+const METATIMEOUT_USAGE = "How long a single call to the MetaStore may take"
+
+const BLOCKTIMEOUT_NAME = "blocktimeout"
+const BLOCKTIMEOUT_USAGE = "How long a single call to a BlockStore may take"
+
+const STREAMTIMEOUT_NAME = "streamtimeout"
+const STREAMTIMEOUT_USAGE = "How long a stream moving a batch of blocks may take"
+
+const RETRIES_NAME = "retries"
+const RETRIES_USAGE = "How often a call that failed for a transient reason is retried (0 never retries)"
+
+const BACKOFF_NAME = "backoff"
+const BACKOFF_USAGE = "The most the first retry waits, every further retry waits up to twice as long"
+
+const CONCURRENCY_NAME = "concurrency"
+const CONCURRENCY_USAGE = "How many files and block transfers are in flight at once"
+
+const PERSERVER_NAME = "perserver"
+const PERSERVER_USAGE = "How many block transfers are in flight at once against one BlockStore"
+
+// where -encrypt reads the passphrase from when there is no keyfile, so it doesn't show up in ps
+const PASSPHRASE_ENV = "SURFSTORE_PASSPHRASE"
+
 const ADDR_NAME = "host:port"
 const ADDR_USAGE = "IP address and port of the MetaStore the client is syncing to"
 
@@ -37,6 +81,19 @@ func main() {
 		w := flag.CommandLine.Output()
 		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
 		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", WATCH_NAME, WATCH_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", POLL_NAME, POLL_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", CHUNKING_NAME, CHUNKING_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", COMPRESS_NAME, COMPRESS_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", ENCRYPT_NAME, ENCRYPT_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", KEYFILE_NAME, KEYFILE_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", METATIMEOUT_NAME, METATIMEOUT_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", BLOCKTIMEOUT_NAME, BLOCKTIMEOUT_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", STREAMTIMEOUT_NAME, STREAMTIMEOUT_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", RETRIES_NAME, RETRIES_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", BACKOFF_NAME, BACKOFF_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", CONCURRENCY_NAME, CONCURRENCY_USAGE)
+		fmt.Fprintf(w, "  -%s: %v\n", PERSERVER_NAME, PERSERVER_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BASEDIR_NAME, BASEDIR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BLOCK_NAME, BLOCK_USAGE)
@@ -44,6 +101,19 @@ func main() {
 
 	// Parse command-line arguments and flags
 	debug := flag.Bool("d", false, DEBUG_USAGE)
+	watch := flag.Bool(WATCH_NAME, false, WATCH_USAGE)
+	poll := flag.Duration(POLL_NAME, surfstore.DEFAULT_POLL_INTERVAL, POLL_USAGE)
+	chunking := flag.String(CHUNKING_NAME, surfstore.FIXED_CHUNKING, CHUNKING_USAGE)
+	compress := flag.String(COMPRESS_NAME, "none", COMPRESS_USAGE)
+	encrypt := flag.Bool(ENCRYPT_NAME, false, ENCRYPT_USAGE)
+	keyfile := flag.String(KEYFILE_NAME, "", KEYFILE_USAGE)
+	metaTimeout := flag.Duration(METATIMEOUT_NAME, surfstore.DEFAULT_META_TIMEOUT, METATIMEOUT_USAGE)
+	blockTimeout := flag.Duration(BLOCKTIMEOUT_NAME, surfstore.DEFAULT_BLOCK_TIMEOUT, BLOCKTIMEOUT_USAGE)
+	streamTimeout := flag.Duration(STREAMTIMEOUT_NAME, surfstore.DEFAULT_STREAM_TIMEOUT, STREAMTIMEOUT_USAGE)
+	retries := flag.Int(RETRIES_NAME, surfstore.DEFAULT_MAX_RETRIES, RETRIES_USAGE)
+	backoff := flag.Duration(BACKOFF_NAME, surfstore.DEFAULT_INITIAL_BACKOFF, BACKOFF_USAGE)
+	concurrency := flag.Int(CONCURRENCY_NAME, surfstore.DEFAULT_CONCURRENCY, CONCURRENCY_USAGE)
+	perServer := flag.Int(PERSERVER_NAME, surfstore.DEFAULT_PER_SERVER_CONCURRENCY, PERSERVER_USAGE)
 	flag.Parse()
 
 	// Use tail arguments to hold non-flag arguments
@@ -57,10 +127,28 @@ func main() {
 	hostPort := args[0]
 	baseDir := args[1]
 	blockSize, err := strconv.Atoi(args[2])
+	if err != nil || blockSize <= 0 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	if *chunking != surfstore.FIXED_CHUNKING && *chunking != surfstore.CDC_CHUNKING {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	codec, err := surfstore.ParseCodec(*compress)
 	if err != nil {
 		flag.Usage()
 		os.Exit(EX_USAGE)
 	}
+	if *metaTimeout <= 0 || *blockTimeout <= 0 || *streamTimeout <= 0 || *retries < 0 || *backoff <= 0 || *concurrency <= 0 || *perServer <= 0 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	// encrypted blocks don't compress, and a keyfile without -encrypt is most likely a mistake
+	if (*encrypt && codec != surfstore.Codec_NONE) || (!(*encrypt) && *keyfile != "") {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
 
 	// Disable log outputs if debug flag is missing
 	if !(*debug) {
@@ -69,5 +157,49 @@ func main() {
 	}
 
 	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, baseDir, blockSize)
-	surfstore.ClientSync(rpcClient)
+	defer rpcClient.Close()
+	rpcClient.Chunking = *chunking
+	rpcClient.Compression = codec
+	rpcClient.Options.MetaTimeout = *metaTimeout
+	rpcClient.Options.BlockTimeout = *blockTimeout
+	rpcClient.Options.StreamTimeout = *streamTimeout
+	rpcClient.Options.MaxRetries = *retries
+	rpcClient.Options.InitialBackoff = *backoff
+	rpcClient.Concurrency = *concurrency
+	rpcClient.PerServerConcurrency = *perServer
+	if *encrypt {
+		var crypter *surfstore.Crypter
+		if *keyfile != "" {
+			crypter, err = surfstore.NewCrypterFromKeyFile(*keyfile)
+		} else if passphrase := os.Getenv(PASSPHRASE_ENV); passphrase != "" {
+			var salt []byte
+			if err = rpcClient.GetEncryptionSalt(&salt); err == nil {
+				crypter, err = surfstore.NewCrypterFromPassphrase(passphrase, salt)
+			}
+		} else {
+			err = fmt.Errorf("-encrypt needs -keyfile or $%s", PASSPHRASE_ENV)
+		}
+		if err != nil {
+			fmt.Println("Error Loading Encryption Key: ", err)
+			os.Exit(EX_USAGE)
+		}
+		rpcClient.Crypter = crypter
+	}
+	if !(*watch) {
+		surfstore.ClientSync(rpcClient)
+		return
+	}
+
+	// stop between two syncs on ctrl-c or kill, so the index is never left half written
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	if err := surfstore.ClientWatch(rpcClient, *poll, surfstore.WATCH_DEBOUNCE, stop); err != nil {
+		fmt.Println("Error During Watch: ", err)
+		os.Exit(1)
+	}
 }
@@ -8,14 +8,17 @@ import (
 	"log"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 // Usage String
-const USAGE_STRING = "./run-server.sh -s <service_type> -p <port> -l -d (blockStoreAddr*)"
+const USAGE_STRING = "./run-server.sh -s <service_type> -p <port> -l -d -datadir <dir> -backend <memory|disk> -i <id> -peers <metaAddr,...> -vnodes <n> -r <replicas> -scrubinterval <duration> -scrubrate <MB/s> -scrubmeta <metaAddr,...> (blockStoreAddr[=weight]*)"
 
 // Set of valid services
 var SERVICE_TYPES = map[string]bool{"meta": true, "block": true, "both": true}
@@ -31,7 +34,7 @@ func main() {
 		flag.VisitAll(func(f *flag.Flag) {
 			fmt.Fprintf(w, "  -%s: %v\n", f.Name, f.Usage)
 		})
-		fmt.Fprintf(w, "  (blockStoreAddr*): BlockStore Address (include self if service type is both)\n")
+		fmt.Fprintf(w, "  (blockStoreAddr[=weight]*): BlockStore Address (include self if service type is both), optionally with its weight on the hash ring\n")
 	}
 
 	// Parse command-line argument flags
@@ -39,14 +42,24 @@ func main() {
 	port := flag.Int("p", 8080, "(default = 8080) Port to accept connections")
 	localOnly := flag.Bool("l", false, "Only listen on localhost")
 	debug := flag.Bool("d", false, "Output log statements")
+	dataDir := flag.String("datadir", "", "Directory for the MetaStore (or raft) write-ahead log and snapshots (in-memory only if empty)")
+	backend := flag.String("backend", surfstore.MEMORY_BACKEND, "BlockStore storage backend: memory, disk (disk stores blocks under <datadir>/blocks)")
+	raftId := flag.Int64("i", 0, "Id of this MetaStore in the raft cluster (index into -peers)")
+	peers := flag.String("peers", "", "Comma separated addresses of every MetaStore in the raft cluster, including this one (no raft if empty)")
+	virtualNodes := flag.Int("vnodes", 0, "Virtual nodes on the hash ring per unit of BlockStore weight (0: one point per BlockStore, as before virtual nodes, weights need vnodes)")
+	replicationFactor := flag.Int("r", 1, "Number of BlockStores every block is stored on")
+	scrubInterval := flag.Duration("scrubinterval", surfstore.DEFAULT_SCRUB_INTERVAL, "How often the BlockStore re-hashes all its blocks to find corrupted ones (0 disables scrubbing)")
+	scrubRate := flag.Int64("scrubrate", surfstore.DEFAULT_SCRUB_RATE/(1024*1024), "How many MB per second the scrubber may read (0 is unlimited)")
+	scrubMeta := flag.String("scrubmeta", "", "Comma separated addresses of the MetaStores to look up the replicas of a corrupted block in, to repair it from (default: this server with -s both)")
 	flag.Parse()
 
 	// Use tail arguments to hold BlockStore address
 	args := flag.Args()
-	blockStoreAddrs := []string{}
-
-	for i := 0; i < len(args); i++ {
-		blockStoreAddrs = append(blockStoreAddrs, args[i])
+	blockStoreAddrs, weights, err := surfstore.ParseBlockStoreArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		flag.Usage()
+		os.Exit(EX_USAGE)
 	}
 
 	// Valid service type argument
@@ -68,27 +81,132 @@ func main() {
 		log.SetOutput(ioutil.Discard)
 	}
 
-	log.Fatal(startServer(addr, strings.ToLower(*service), blockStoreAddrs))
+	config := serverConfig{
+		dataDir:           *dataDir,
+		backendType:       strings.ToLower(*backend),
+		raftId:            *raftId,
+		weights:           weights,
+		virtualNodes:      *virtualNodes,
+		replicationFactor: *replicationFactor,
+		scrubInterval:     *scrubInterval,
+		scrubRate:         *scrubRate * 1024 * 1024,
+	}
+	if *peers != "" {
+		config.raftPeers = strings.Split(*peers, surfstore.CONFIG_DELIMITER)
+	}
+	if *scrubMeta != "" {
+		config.scrubMetaStores = strings.Split(*scrubMeta, surfstore.CONFIG_DELIMITER)
+	} else if strings.ToLower(*service) == "both" {
+		config.scrubMetaStores = []string{addr}
+	}
+
+	log.Fatal(startServer(addr, strings.ToLower(*service), blockStoreAddrs, config))
+}
+
+type serverConfig struct {
+	dataDir     string
+	backendType string
+	raftId      int64
+	raftPeers   []string
+	// placement of the blockstores on the hash ring
+	weights           map[string]int
+	virtualNodes      int
+	replicationFactor int
+	// background scrubbing of the blockstore
+	scrubInterval   time.Duration
+	scrubRate       int64    // bytes per second
+	scrubMetaStores []string // where the scrubber finds the replicas of a block
+}
+
+// registerMetaStore registers a plain MetaStore, or a raft replicated one if the server is part of a cluster
+func registerMetaStore(grpcServer *grpc.Server, blockStoreAddrs []string, config serverConfig) error {
+	ring, err := surfstore.NewWeightedConsistentHashRing(blockStoreAddrs, config.weights, config.virtualNodes, config.replicationFactor)
+	if err != nil {
+		return err
+	}
+	if len(config.raftPeers) == 0 {
+		metaStore, err := newMetaStore(ring, config.dataDir)
+		if err != nil {
+			return fmt.Errorf("failed to recover metastore: %v", err)
+		}
+		surfstore.RegisterMetaStoreServer(grpcServer, metaStore)
+		return nil
+	}
+
+	raftServer, err := newRaftSurfstore(ring, config)
+	if err != nil {
+		return fmt.Errorf("failed to recover raft state: %v", err)
+	}
+	surfstore.RegisterMetaStoreServer(grpcServer, raftServer)
+	surfstore.RegisterRaftSurfstoreServer(grpcServer, raftServer)
+	go raftServer.Run()
+	return nil
+}
+
+func newRaftSurfstore(ring *surfstore.ConsistentHashRing, config serverConfig) (*surfstore.RaftSurfstore, error) {
+	if config.dataDir == "" {
+		return surfstore.NewRaftSurfstore(config.raftId, config.raftPeers, ring)
+	}
+	return surfstore.NewDurableRaftSurfstore(config.raftId, config.raftPeers, ring, config.dataDir)
+}
+
+func newMetaStore(ring *surfstore.ConsistentHashRing, dataDir string) (*surfstore.MetaStore, error) {
+	if dataDir == "" {
+		return surfstore.NewMetaStoreWithRing(ring), nil
+	}
+	return surfstore.NewDurableMetaStore(ring, dataDir)
 }
 
-func startServer(hostAddr string, serviceType string, blockStoreAddrs []string) error {
+// newBlockStore opens the blockstore, and starts its scrubber unless scrubbing is off
+func newBlockStore(config serverConfig) (*surfstore.BlockStore, error) {
+	if config.backendType == surfstore.DISK_BACKEND && config.dataDir == "" {
+		return nil, fmt.Errorf("the disk backend requires -datadir")
+	}
+	backend, err := surfstore.NewBlockBackend(config.backendType, filepath.Join(config.dataDir, "blocks"))
+	if err != nil {
+		return nil, err
+	}
+	blockStore := surfstore.NewBlockStoreWithBackend(backend)
+	if config.scrubInterval > 0 {
+		blockStore.Scrubber = surfstore.NewScrubber(backend, config.scrubInterval, config.scrubRate, config.scrubMetaStores)
+		if config.dataDir != "" {
+			blockStore.Scrubber.StateFile = filepath.Join(config.dataDir, surfstore.SCRUB_STATE_FILENAME)
+		}
+		go blockStore.Scrubber.Run()
+	}
+	return blockStore, nil
+}
+
+func startServer(hostAddr string, serviceType string, blockStoreAddrs []string, config serverConfig) error {
 	// Create a new RPC server
-	grpcServer := grpc.NewServer()
+	// clients keep their connections open and ping them while idle
+	grpcServer := grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             surfstore.KEEPALIVE_MIN_TIME,
+		PermitWithoutStream: true,
+	}))
 
 	// Register RPC services
 	if serviceType == "both" {
-		metaStore := surfstore.NewMetaStore(blockStoreAddrs)
-		surfstore.RegisterMetaStoreServer(grpcServer, metaStore)
-		blockStore := surfstore.NewBlockStore()
+		if err := registerMetaStore(grpcServer, blockStoreAddrs, config); err != nil {
+			return err
+		}
+		blockStore, err := newBlockStore(config)
+		if err != nil {
+			return fmt.Errorf("failed to open blockstore: %v", err)
+		}
 		surfstore.RegisterBlockStoreServer(grpcServer, blockStore)
 	}
 	if serviceType == "meta" {
-		metaStore := surfstore.NewMetaStore(blockStoreAddrs)
-		surfstore.RegisterMetaStoreServer(grpcServer, metaStore)
+		if err := registerMetaStore(grpcServer, blockStoreAddrs, config); err != nil {
+			return err
+		}
 	}
 	if serviceType == "block" {
 		fmt.Println("blockstore")
-		blockStore := surfstore.NewBlockStore()
+		blockStore, err := newBlockStore(config)
+		if err != nil {
+			return fmt.Errorf("failed to open blockstore: %v", err)
+		}
 		surfstore.RegisterBlockStoreServer(grpcServer, blockStore)
 	}
 
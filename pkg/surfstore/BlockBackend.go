@@ -0,0 +1,304 @@
+package surfstore
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+server side:
+the blockstore keeps its blocks in a BlockBackend. The in-memory backend is the original map, the disk backend
+stores every block as its own file named by its hash, sharded into two levels of directories by the hash prefix
+(e.g. <dir>/ab/cd/abcd...), so the blockstore survives restarts and can hold more data than fits in memory.
+*/
+
+// Implementations must be safe for concurrent use, the blockstore calls them from concurrent gRPC handlers.
+type BlockBackend interface {
+	// Get the block stored under hash, returns an error if it is not there
+	GetBlock(hash string) (*Block, error)
+
+	// Store block under hash
+	PutBlock(hash string, block *Block) error
+
+	// Whether a block is stored under hash
+	HasBlock(hash string) (bool, error)
+
+	// All hashes stored in the backend
+	GetBlockHashes() ([]string, error)
+
+	// Take the block stored under hash out of the store (it is no longer served or listed) but keep it for inspection
+	QuarantineBlock(hash string) error
+
+	// Remove the block stored under hash, returns whether it was there
+	DeleteBlock(hash string) (bool, error)
+}
+
+// The names accepted by NewBlockBackend
+const MEMORY_BACKEND string = "memory"
+const DISK_BACKEND string = "disk"
+
+func NewBlockBackend(backendType string, dir string) (BlockBackend, error) {
+	switch backendType {
+	case MEMORY_BACKEND:
+		return NewMemoryBlockBackend(), nil
+	case DISK_BACKEND:
+		return NewDiskBlockBackend(dir)
+	default:
+		return nil, fmt.Errorf("unknown block backend %q", backendType)
+	}
+}
+
+/* In-memory backend */
+
+type MemoryBlockBackend struct {
+	mu          sync.RWMutex
+	BlockMap    map[string]*Block
+	Quarantined map[string]*Block
+}
+
+func (mb *MemoryBlockBackend) GetBlock(hash string) (*Block, error) {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+	block, ok := mb.BlockMap[hash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	return block, nil
+}
+
+func (mb *MemoryBlockBackend) PutBlock(hash string, block *Block) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.BlockMap[hash] = block
+	return nil
+}
+
+func (mb *MemoryBlockBackend) HasBlock(hash string) (bool, error) {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+	_, ok := mb.BlockMap[hash]
+	return ok, nil
+}
+
+func (mb *MemoryBlockBackend) GetBlockHashes() ([]string, error) {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+	hashes := []string{}
+	for hash := range mb.BlockMap {
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func (mb *MemoryBlockBackend) QuarantineBlock(hash string) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	block, ok := mb.BlockMap[hash]
+	if !ok {
+		return fmt.Errorf("block %s not found", hash)
+	}
+	mb.Quarantined[hash] = block
+	delete(mb.BlockMap, hash)
+	return nil
+}
+
+func (mb *MemoryBlockBackend) DeleteBlock(hash string) (bool, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	_, ok := mb.BlockMap[hash]
+	delete(mb.BlockMap, hash)
+	return ok, nil
+}
+
+var _ BlockBackend = new(MemoryBlockBackend)
+
+func NewMemoryBlockBackend() *MemoryBlockBackend {
+	return &MemoryBlockBackend{
+		BlockMap:    map[string]*Block{},
+		Quarantined: map[string]*Block{},
+	}
+}
+
+/* On-disk content-addressed backend */
+
+const BLOCK_TMP_SUFFIX string = ".tmp"
+
+// quarantined blocks are moved to <dir>/quarantine/<hash>, the name can't clash with a two character shard directory
+const QUARANTINE_DIR string = "quarantine"
+
+// Every PutBlock writes its own temp file and renames it into place, so concurrent writers
+// (even of the same block) never see a partial file and no extra locking is needed.
+type DiskBlockBackend struct {
+	Dir string
+}
+
+// blockPath returns where the block with the given hash lives, e.g. <dir>/ab/cd/abcd...
+func (db *DiskBlockBackend) blockPath(hash string) (string, error) {
+	// the hash comes from the client, make sure it cannot escape the block directory
+	if len(hash) < 4 || strings.ContainsAny(hash, "/\\.") {
+		return "", fmt.Errorf("invalid block hash %q", hash)
+	}
+	return filepath.Join(db.Dir, hash[0:2], hash[2:4], hash), nil
+}
+
+func (db *DiskBlockBackend) GetBlock(hash string) (*Block, error) {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block := &Block{}
+	if err := proto.Unmarshal(data, block); err != nil {
+		return nil, fmt.Errorf("corrupted block %s: %v", hash, err)
+	}
+	return block, nil
+}
+
+// PutBlock writes the block to a temp file in the same shard directory, fsyncs it and renames it into place,
+// so a block file is either complete or not there at all.
+func (db *DiskBlockBackend) PutBlock(hash string, block *Block) error {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return err
+	}
+	// blocks are content addressed, if it is already there it is the same block, unless it rotted: then the
+	// new copy replaces it
+	if existing, err := db.GetBlock(hash); err == nil {
+		if _, err := VerifyBlock(hash, existing); err == nil {
+			return nil
+		}
+	}
+
+	data, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+
+	shardDir := filepath.Dir(path)
+	if err := os.MkdirAll(shardDir, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(shardDir, hash+"-*"+BLOCK_TMP_SUFFIX)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return syncDir(shardDir)
+}
+
+func (db *DiskBlockBackend) HasBlock(hash string) (bool, error) {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return false, nil
+	}
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (db *DiskBlockBackend) GetBlockHashes() ([]string, error) {
+	hashes := []string{}
+	err := filepath.WalkDir(db.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path == filepath.Join(db.Dir, QUARANTINE_DIR) {
+			return filepath.SkipDir
+		}
+		// skip directories and temp files left by a crash during PutBlock
+		if d.IsDir() || strings.HasSuffix(d.Name(), BLOCK_TMP_SUFFIX) {
+			return nil
+		}
+		hashes = append(hashes, d.Name())
+		return nil
+	})
+	return hashes, err
+}
+
+// QuarantineBlock moves the block file into the quarantine directory, a block quarantined before is replaced
+func (db *DiskBlockBackend) QuarantineBlock(hash string) error {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return err
+	}
+	quarantineDir := filepath.Join(db.Dir, QUARANTINE_DIR)
+	if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(quarantineDir, hash)); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return syncDir(quarantineDir)
+}
+
+func (db *DiskBlockBackend) DeleteBlock(hash string) (bool, error) {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return false, err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, syncDir(filepath.Dir(path))
+}
+
+var _ BlockBackend = new(DiskBlockBackend)
+
+func NewDiskBlockBackend(dir string) (*DiskBlockBackend, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the disk block backend needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// clean up temp files left by a crash in the middle of PutBlock
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(d.Name(), BLOCK_TMP_SUFFIX) {
+			os.Remove(path)
+		}
+		return nil
+	})
+
+	return &DiskBlockBackend{Dir: dir}, nil
+}
@@ -0,0 +1,145 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const TEST_BLOCKS int = 200
+
+func testBackends(t *testing.T) map[string]BlockBackend {
+	disk, err := NewDiskBlockBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]BlockBackend{
+		MEMORY_BACKEND: NewMemoryBlockBackend(),
+		DISK_BACKEND:   disk,
+	}
+}
+
+// writers store overlapping sets of blocks (so the same block is often put twice at once) while readers
+// keep asking for them, the backend must end up with every block exactly once
+func TestBlockBackendConcurrentPutAndHas(t *testing.T) {
+	blocks := make(map[string]*Block)
+	hashes := []string{}
+	for i := 0; i < TEST_BLOCKS; i++ {
+		data := []byte(fmt.Sprintf("block %d", i))
+		hash := GetBlockHashString(data)
+		blocks[hash] = &Block{BlockData: data, BlockSize: int32(len(data))}
+		hashes = append(hashes, hash)
+	}
+
+	for name, backend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := w * TEST_BLOCKS / 8; i < len(hashes); i++ {
+						if err := backend.PutBlock(hashes[i], blocks[hashes[i]]); err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}(w)
+			}
+			for r := 0; r < 4; r++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for _, hash := range hashes {
+						ok, err := backend.HasBlock(hash)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						// a block that is there has to be complete
+						if ok {
+							block, err := backend.GetBlock(hash)
+							if err != nil {
+								t.Error(err)
+								return
+							}
+							if _, err := VerifyBlock(hash, block); err != nil {
+								t.Error(err)
+							}
+						}
+					}
+					if _, err := backend.GetBlockHashes(); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			stored, err := backend.GetBlockHashes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(stored)
+			want := append([]string{}, hashes...)
+			sort.Strings(want)
+			if fmt.Sprint(stored) != fmt.Sprint(want) {
+				t.Errorf("backend has %d blocks, expected %d", len(stored), len(want))
+			}
+		})
+	}
+}
+
+// a block that rotted on disk is replaced by the next copy put
+func TestDiskBlockBackendReplacesRottenBlock(t *testing.T) {
+	disk, err := NewDiskBlockBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockStore := NewBlockStoreWithBackend(disk)
+	data := []byte("a block that will rot")
+	hash := GetBlockHashString(data)
+	block := &Block{BlockData: data, BlockSize: int32(len(data)), Hash: hash}
+	if _, err := blockStore.PutBlock(context.Background(), block); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := disk.blockPath(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotten, _ := proto.Marshal(&Block{BlockData: []byte("a block that has rotted"), BlockSize: int32(len(data))})
+	if err := os.WriteFile(path, rotten, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := blockStore.PutBlock(context.Background(), block); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := disk.GetBlock(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyBlock(hash, stored); err != nil {
+		t.Errorf("the rotten block was not replaced: %v", err)
+	}
+}
+
+func TestIsBlockCorruption(t *testing.T) {
+	corruption := &BlockCorruptionError{Hash: "a", ActualHash: "b"}
+	remote := status.Error(codes.DataLoss, corruption.Error())
+	for _, err := range []error{corruption, remote, fmt.Errorf("could not get block: %w", corruption), fmt.Errorf("could not put block: %w", remote)} {
+		if !IsBlockCorruption(err) {
+			t.Errorf("%v is not a block corruption", err)
+		}
+	}
+	if IsBlockCorruption(status.Error(codes.Unavailable, "down")) || IsBlockCorruption(fmt.Errorf("other")) {
+		t.Errorf("other errors are block corruptions")
+	}
+}
@@ -0,0 +1,148 @@
+package surfstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+/*
+blocks can be compressed on the wire and at rest. The client compresses a block before PutBlock (if that makes it smaller),
+the blockstore stores it as it came, and whoever reads it decompresses it. The block hash is always the hash of the
+uncompressed data, so the same content deduplicates to the same block whichever codec the client used.
+Since the hash is also the name of the block, it is checked on both ends: the client sends the hash it expects with
+every block it puts and the blockstore refuses a block that hashes to something else, and the client checks every block
+it gets against the hash it asked for before using it.
+*/
+
+// the largest block we agree to decompress, so a tiny malicious block can't make us allocate gigabytes
+const MAX_BLOCK_SIZE int = 64 * 1024 * 1024
+
+// both are safe for concurrent use
+var zstdEncoder, _ = zstd.NewWriter(nil)
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(MAX_BLOCK_SIZE)))
+
+// ParseCodec turns a codec name (none, gzip or zstd) into a Codec
+func ParseCodec(name string) (Codec, error) {
+	codec, ok := Codec_value[strings.ToUpper(name)]
+	if !ok {
+		return Codec_NONE, fmt.Errorf("unknown codec %q", name)
+	}
+	return Codec(codec), nil
+}
+
+// CompressBlock builds the block for data, compressed with codec unless that doesn't make it smaller
+func CompressBlock(data []byte, codec Codec) (*Block, error) {
+	var compressed []byte
+	switch codec {
+	case Codec_NONE:
+		return &Block{BlockData: data, BlockSize: int32(len(data))}, nil
+	case Codec_GZIP:
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		compressed = buf.Bytes()
+	case Codec_ZSTD:
+		compressed = zstdEncoder.EncodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unknown codec %v", codec)
+	}
+
+	// already compressed data (photos, archives) only gets bigger
+	if len(compressed) >= len(data) {
+		return &Block{BlockData: data, BlockSize: int32(len(data))}, nil
+	}
+	return &Block{BlockData: compressed, BlockSize: int32(len(data)), Codec: codec}, nil
+}
+
+// DecompressBlock returns the uncompressed data of the block, checking it has the size the block claims
+func DecompressBlock(block *Block) ([]byte, error) {
+	if block.Codec == Codec_NONE {
+		return block.BlockData, nil
+	}
+	if block.BlockSize < 0 || int(block.BlockSize) > MAX_BLOCK_SIZE {
+		return nil, fmt.Errorf("invalid block size %d", block.BlockSize)
+	}
+
+	var data []byte
+	switch block.Codec {
+	case Codec_GZIP:
+		reader, err := gzip.NewReader(bytes.NewReader(block.BlockData))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		// read one byte more than expected, so a block that is too big is caught without reading all of it
+		data, err = io.ReadAll(io.LimitReader(reader, int64(block.BlockSize)+1))
+		if err != nil {
+			return nil, err
+		}
+	case Codec_ZSTD:
+		var err error
+		data, err = zstdDecoder.DecodeAll(block.BlockData, make([]byte, 0, block.BlockSize))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown codec %v", block.Codec)
+	}
+
+	if len(data) != int(block.BlockSize) {
+		return nil, fmt.Errorf("block decompressed to %d bytes, expected %d", len(data), block.BlockSize)
+	}
+	return data, nil
+}
+
+// BlockCorruptionError means the data of a block doesn't hash to the hash it was stored or asked for under
+type BlockCorruptionError struct {
+	Hash       string // the hash the block should have
+	ActualHash string // the hash of the data we got
+	Source     string // the blockstore the block came from, empty if not known
+}
+
+func (e *BlockCorruptionError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("block %s is corrupted, its data hashes to %s", e.Hash, e.ActualHash)
+	}
+	return fmt.Sprintf("block %s from %s is corrupted, its data hashes to %s", e.Hash, e.Source, e.ActualHash)
+}
+
+// a blockstore refusing a corrupted block answers with DataLoss, the error type doesn't survive gRPC
+func (e *BlockCorruptionError) GRPCStatus() *status.Status {
+	return status.New(codes.DataLoss, e.Error())
+}
+
+// IsBlockCorruption reports whether err is a BlockCorruptionError, here or on the blockstore that returned it
+func IsBlockCorruption(err error) bool {
+	var corruption *BlockCorruptionError
+	if errors.As(err, &corruption) {
+		return true
+	}
+	// status.Code doesn't look into wrapped errors
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &grpcErr) && grpcErr.GRPCStatus().Code() == codes.DataLoss
+}
+
+// VerifyBlock returns the uncompressed data of the block, or a BlockCorruptionError if it doesn't hash to hash
+func VerifyBlock(hash string, block *Block) ([]byte, error) {
+	data, err := DecompressBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	if actualHash := GetBlockHashString(data); actualHash != hash {
+		return nil, &BlockCorruptionError{Hash: hash, ActualHash: actualHash}
+	}
+	return data, nil
+}
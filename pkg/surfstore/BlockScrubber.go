@@ -0,0 +1,224 @@
+package surfstore
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+server side:
+the scrubber re-reads every block of the blockstore once per Interval and checks that it still hashes to its name, so bit rot
+or a block file edited by hand is found before a client downloads it. Reading is throttled to Rate bytes per second so a pass
+doesn't take the disk away from the clients. A corrupted block is quarantined: it is taken out of the store, so HasBlocks
+reports it missing and the next upload of a file with that block sends it again. The metastore is asked which blockstores
+hold the replicas of the block (the ring decides, so they change with the membership), and a good copy is fetched from the
+first one that has it and stored right away.
+The time of the last pass is kept in StateFile, so a restarted server goes on with the same schedule instead of
+scrubbing everything again, or never if it restarts more often than once per Interval.
+*/
+
+const DEFAULT_SCRUB_INTERVAL time.Duration = 24 * time.Hour
+const DEFAULT_SCRUB_RATE int64 = 8 * 1024 * 1024 // bytes per second
+
+// how long after the server started the first pass is, unless StateFile says the last one was more recent
+const SCRUB_START_DELAY time.Duration = time.Minute
+
+// the name of the StateFile the server keeps in its data directory
+const SCRUB_STATE_FILENAME string = "scrub-last-pass"
+
+// how many corrupted blocks GetScrubStatus reports, the oldest are dropped first
+const MAX_SCRUB_REPORTS int = 1000
+
+type Scrubber struct {
+	Backend   BlockBackend
+	Interval  time.Duration // from the end of one pass to the start of the next
+	Rate      int64         // bytes read per second, 0 is unlimited
+	StateFile string        // where the time of the last pass is kept, "" to not keep it
+
+	client RPCClient // asks the metastores for the replicas of a block, and the blockstores for a copy
+
+	mu     sync.Mutex
+	status *ScrubStatus
+}
+
+// metaStoreAddrs are the metastores (any of a raft cluster) that know the ring, without them blocks are not repaired
+func NewScrubber(backend BlockBackend, interval time.Duration, rate int64, metaStoreAddrs []string) *Scrubber {
+	return &Scrubber{
+		Backend:  backend,
+		Interval: interval,
+		Rate:     rate,
+		client:   RPCClient{MetaStoreAddrs: metaStoreAddrs, Options: DefaultRPCOptions(), conns: NewConnPool()},
+		status:   &ScrubStatus{Enabled: true},
+	}
+}
+
+// Run scrubs the blockstore every Interval, forever. The first pass starts SCRUB_START_DELAY after the server did,
+// or one Interval after the last pass in StateFile if that is later.
+func (sc *Scrubber) Run() {
+	next := time.Now().Add(SCRUB_START_DELAY)
+	if lastPass, ok := sc.loadLastPass(); ok && lastPass.Add(sc.Interval).After(next) {
+		next = lastPass.Add(sc.Interval)
+	}
+	for {
+		sc.mu.Lock()
+		sc.status.NextPass = next.Unix()
+		sc.mu.Unlock()
+
+		time.Sleep(time.Until(next))
+		sc.Scrub()
+		sc.saveLastPass(time.Now())
+		next = time.Now().Add(sc.Interval)
+	}
+}
+
+func (sc *Scrubber) loadLastPass() (time.Time, bool) {
+	if sc.StateFile == "" {
+		return time.Time{}, false
+	}
+	contents, err := os.ReadFile(sc.StateFile)
+	if err != nil {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+	if err != nil {
+		log.Println("[Scrubber] ignoring", sc.StateFile, ":", err)
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+func (sc *Scrubber) saveLastPass(lastPass time.Time) {
+	if sc.StateFile == "" {
+		return
+	}
+	if err := os.WriteFile(sc.StateFile, []byte(strconv.FormatInt(lastPass.Unix(), 10)+"\n"), 0644); err != nil {
+		log.Println("[Scrubber] could not save the time of the pass:", err)
+	}
+}
+
+// Scrub makes one pass over every block and returns how many corrupted blocks it found
+func (sc *Scrubber) Scrub() int {
+	hashes, err := sc.Backend.GetBlockHashes()
+	if err != nil {
+		log.Println("[Scrubber] could not list blocks:", err)
+		return 0
+	}
+
+	start := time.Now()
+	sc.mu.Lock()
+	sc.status.Running = true
+	sc.status.BlocksChecked = 0
+	sc.status.BlocksTotal = int64(len(hashes))
+	sc.status.BytesChecked = 0
+	sc.status.PassStarted = start.Unix()
+	sc.status.NextPass = 0
+	sc.mu.Unlock()
+
+	var bytesRead int64
+	corrupted := 0
+	for _, hash := range hashes {
+		var report *CorruptBlock
+		block, err := sc.Backend.GetBlock(hash)
+		if err != nil {
+			// gone since we listed it, or there but not even readable
+			if ok, _ := sc.Backend.HasBlock(hash); ok {
+				report = &CorruptBlock{Hash: hash}
+				log.Println("[Scrubber] could not read block", hash, ":", err)
+			}
+		} else {
+			bytesRead += int64(len(block.BlockData))
+			if _, err := VerifyBlock(hash, block); err != nil {
+				report = &CorruptBlock{Hash: hash}
+				if corruption, ok := err.(*BlockCorruptionError); ok {
+					report.ActualHash = corruption.ActualHash
+				}
+				log.Println("[Scrubber]", err)
+			}
+		}
+
+		if report != nil {
+			corrupted++
+			report.FoundAt = time.Now().Unix()
+			sc.repair(report)
+		}
+
+		sc.mu.Lock()
+		sc.status.BlocksChecked++
+		sc.status.BytesChecked = bytesRead
+		if report != nil {
+			sc.status.CorruptBlocks = append(sc.status.CorruptBlocks, report)
+			if len(sc.status.CorruptBlocks) > MAX_SCRUB_REPORTS {
+				sc.status.CorruptBlocks = sc.status.CorruptBlocks[len(sc.status.CorruptBlocks)-MAX_SCRUB_REPORTS:]
+			}
+		}
+		sc.mu.Unlock()
+
+		// sleep until reading this much at Rate would have taken as long
+		if sc.Rate > 0 {
+			due := start.Add(time.Duration(float64(bytesRead) / float64(sc.Rate) * float64(time.Second)))
+			if wait := time.Until(due); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+	}
+
+	sc.mu.Lock()
+	sc.status.Running = false
+	sc.status.Passes++
+	sc.status.PassFinished = time.Now().Unix()
+	sc.mu.Unlock()
+
+	log.Println("[Scrubber] checked", len(hashes), "blocks in", time.Since(start), "found", corrupted, "corrupted")
+	return corrupted
+}
+
+// repair quarantines the corrupted block and stores a good copy from the first of its replicas that has one
+func (sc *Scrubber) repair(report *CorruptBlock) {
+	if err := sc.Backend.QuarantineBlock(report.Hash); err != nil {
+		report.Error = fmt.Sprintf("could not quarantine the block: %v", err)
+		return
+	}
+	if len(sc.client.MetaStoreAddrs) == 0 {
+		report.Error = "no metastore to find the other replicas from"
+		return
+	}
+	m := make(map[string][]string)
+	if err := sc.client.GetBlockStoreMap([]string{report.Hash}, &m); err != nil {
+		report.Error = fmt.Sprintf("could not get the replicas of the block: %v", err)
+		return
+	}
+
+	// our own copy is quarantined, so asking ourselves just fails like a replica without the block
+	for _, replica := range getBlockReplicas(m)[report.Hash] {
+		peer := strings.ReplaceAll(replica, "blockstore", "")
+		var block Block
+		// GetBlock checks the hash, so a replica whose copy is corrupted too is skipped
+		if err := sc.client.GetBlock(report.Hash, peer, &block); err != nil {
+			log.Println("[Scrubber] could not get block", report.Hash, "from", peer, ":", err)
+			continue
+		}
+		if err := sc.Backend.PutBlock(report.Hash, &block); err != nil {
+			report.Error = fmt.Sprintf("could not store the copy from %s: %v", peer, err)
+			return
+		}
+		report.Repaired = true
+		report.RepairedFrom = peer
+		log.Println("[Scrubber] repaired block", report.Hash, "from", peer)
+		return
+	}
+	report.Error = "no other blockstore has a good copy"
+}
+
+// Status returns a copy of the scrub progress, safe to use while the scrubber goes on
+func (sc *Scrubber) Status() *ScrubStatus {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return proto.Clone(sc.status).(*ScrubStatus)
+}
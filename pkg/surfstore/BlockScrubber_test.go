@@ -0,0 +1,66 @@
+package surfstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+)
+
+// the scrubber finds the replicas of a corrupted block through the metastore's ring and repairs it from one of them
+func TestScrubberRepairsFromRingReplicas(t *testing.T) {
+	backends := []*MemoryBlockBackend{NewMemoryBlockBackend(), NewMemoryBlockBackend()}
+	addrs := []string{}
+	for _, backend := range backends {
+		addrs = append(addrs, serveTest(t, func(grpcServer *grpc.Server) {
+			RegisterBlockStoreServer(grpcServer, NewBlockStoreWithBackend(backend))
+		}))
+	}
+	ring, err := NewWeightedConsistentHashRing(addrs, nil, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metaAddr := serveTest(t, func(grpcServer *grpc.Server) {
+		RegisterMetaStoreServer(grpcServer, NewMetaStoreWithRing(ring))
+	})
+
+	data := []byte("a replicated block")
+	hash := GetBlockHashString(data)
+	for _, backend := range backends {
+		backend.PutBlock(hash, &Block{BlockData: data, BlockSize: int32(len(data))})
+	}
+	backends[0].BlockMap[hash] = &Block{BlockData: []byte("a rotten block"), BlockSize: int32(len(data))}
+
+	scrubber := NewScrubber(backends[0], time.Hour, 0, []string{metaAddr})
+	if corrupted := scrubber.Scrub(); corrupted != 1 {
+		t.Fatalf("found %d corrupted blocks, expected 1", corrupted)
+	}
+	report := scrubber.Status().CorruptBlocks[0]
+	if !report.Repaired || report.RepairedFrom != addrs[1] {
+		t.Fatalf("block was not repaired from %s: %+v", addrs[1], report)
+	}
+	block, err := backends[0].GetBlock(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyBlock(hash, block); err != nil {
+		t.Errorf("the repaired block is still corrupted: %v", err)
+	}
+}
+
+func TestScrubberLastPass(t *testing.T) {
+	scrubber := NewScrubber(NewMemoryBlockBackend(), time.Hour, 0, nil)
+	if _, ok := scrubber.loadLastPass(); ok {
+		t.Errorf("a scrubber without a state file has a last pass")
+	}
+	scrubber.StateFile = filepath.Join(t.TempDir(), SCRUB_STATE_FILENAME)
+	if _, ok := scrubber.loadLastPass(); ok {
+		t.Errorf("a missing state file has a last pass")
+	}
+	now := time.Unix(time.Now().Unix(), 0)
+	scrubber.saveLastPass(now)
+	if lastPass, ok := scrubber.loadLastPass(); !ok || !lastPass.Equal(now) {
+		t.Errorf("last pass %v (%v), expected %v", lastPass, ok, now)
+	}
+}
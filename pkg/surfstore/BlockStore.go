@@ -3,7 +3,10 @@ package surfstore
 import (
 	context "context"
 	"fmt"
+	"io"
 
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -15,37 +18,98 @@ we can just use this API and get result.
 */
 
 type BlockStore struct {
-	BlockMap map[string]*Block
+	Backend  BlockBackend
+	Scrubber *Scrubber // nil if scrubbing is off
 	UnimplementedBlockStoreServer
 }
 
 // put block to the server, which will be used in the download process when the client wants to download files from the server side
 // Stores block b in the key-value store, indexed by hash value h
 func (bs *BlockStore) GetBlock(ctx context.Context, blockHash *BlockHash) (*Block, error) { // * means the pointer and creat new object, return the reference
-	block, ok := bs.BlockMap[blockHash.Hash]
-	if !ok {
-		return nil, fmt.Errorf("GetBlock wrong")
+	block, err := bs.Backend.GetBlock(blockHash.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlock wrong: %v", err)
 	} else {
-		return &Block{BlockData: block.GetBlockData(), BlockSize: block.GetBlockSize()}, nil // & means we get the reference, and write something on the reference
+		return &Block{BlockData: block.GetBlockData(), BlockSize: block.GetBlockSize(), Codec: block.GetCodec()}, nil // & means we get the reference, and write something on the reference
 	}
 }
 
 // get block from the server, which will be used in the upload process when the client wants to upload files to the server side
 // Retrieves a block indexed by hash value h
+// A compressed block is stored compressed, but its hash is the hash of the uncompressed data.
 func (bs *BlockStore) PutBlock(ctx context.Context, block *Block) (*Success, error) {
-	hash := GetBlockHashString(block.BlockData)
-	bs.BlockMap[hash] = block
+	if _, err := bs.putBlock(block); err != nil {
+		return &Success{Flag: false}, err
+	}
 	return &Success{Flag: true}, nil
 }
 
+// putBlock stores the block under the hash of its uncompressed data and returns that hash.
+// A block that doesn't hash to the hash the client expects got corrupted on the way and is refused (DataLoss).
+func (bs *BlockStore) putBlock(block *Block) (string, error) {
+	data, err := DecompressBlock(block)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "could not decompress block: %v", err)
+	}
+	hash := GetBlockHashString(data)
+	if block.Hash != "" && block.Hash != hash {
+		return "", &BlockCorruptionError{Hash: block.Hash, ActualHash: hash}
+	}
+	// the expected hash is only for the check, the block is stored under its hash anyway
+	if err := bs.Backend.PutBlock(hash, &Block{BlockData: block.BlockData, BlockSize: block.BlockSize, Codec: block.Codec}); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// PutBlocks stores every block sent on the stream and acknowledges each one with its hash as soon as it is stored,
+// so the client knows which blocks made it if the stream breaks. A block that can't be stored ends the stream.
+func (bs *BlockStore) PutBlocks(stream BlockStore_PutBlocksServer) error {
+	for {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		hash, err := bs.putBlock(block)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&BlockHash{Hash: hash}); err != nil {
+			return err
+		}
+	}
+}
+
+// GetBlocks streams the blocks back in the order of the hashes, a missing block ends the stream with NotFound
+func (bs *BlockStore) GetBlocks(blockHashes *BlockHashes, stream BlockStore_GetBlocksServer) error {
+	for _, hash := range blockHashes.Hashes {
+		block, err := bs.Backend.GetBlock(hash)
+		if err != nil {
+			return status.Errorf(codes.NotFound, "GetBlocks wrong: %v", err)
+		}
+		if err := stream.Send(&Block{BlockData: block.GetBlockData(), BlockSize: block.GetBlockSize(), Codec: block.GetCodec()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Given an input hashlist,
-// returns an output hashlist containing the subset of hashlist_in that are stored in the key-value store
+// returns an output hashlist containing the subset of hashlist_in that are stored in the key-value store.
+// Only an existence check, the rebalancer asks about large sets. Rotten blocks are caught by the scrubber and by
+// GetBlock/GetBlocks.
 func (bs *BlockStore) HasBlocks(ctx context.Context, blockHashesIn *BlockHashes) (*BlockHashes, error) {
 	hashes := blockHashesIn.Hashes
 	subHashes := []string{}
 	for _, hash := range hashes {
-		block, _ := bs.GetBlock(ctx, &BlockHash{Hash: hash})
-		if block != nil {
+		ok, err := bs.Backend.HasBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
 			subHashes = append(subHashes, hash)
 		}
 	}
@@ -54,18 +118,45 @@ func (bs *BlockStore) HasBlocks(ctx context.Context, blockHashesIn *BlockHashes)
 
 // Return a list containing all blockHashes on this block server
 func (bs *BlockStore) GetBlockHashes(ctx context.Context, _ *emptypb.Empty) (*BlockHashes, error) {
-	hashes := []string{}
-	for hash := range bs.BlockMap {
-		hashes = append(hashes, hash)
+	hashes, err := bs.Backend.GetBlockHashes()
+	if err != nil {
+		return nil, err
 	}
 	return &BlockHashes{Hashes: hashes}, nil
 }
 
+// Progress of the scrubber and the corrupted blocks it found, not Enabled if the blockstore doesn't scrub
+func (bs *BlockStore) GetScrubStatus(ctx context.Context, _ *emptypb.Empty) (*ScrubStatus, error) {
+	if bs.Scrubber == nil {
+		return &ScrubStatus{Enabled: false}, nil
+	}
+	return bs.Scrubber.Status(), nil
+}
+
+// DeleteBlocks drops the given blocks, the rebalancer calls it for blocks whose owners changed and that their new owners have
+func (bs *BlockStore) DeleteBlocks(ctx context.Context, blockHashesIn *BlockHashes) (*BlockHashes, error) {
+	deleted := []string{}
+	for _, hash := range blockHashesIn.Hashes {
+		ok, err := bs.Backend.DeleteBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deleted = append(deleted, hash)
+		}
+	}
+	return &BlockHashes{Hashes: deleted}, nil
+}
+
 // This line guarantees all method for BlockStore are implemented
 var _ BlockStoreInterface = new(BlockStore)
 
 func NewBlockStore() *BlockStore {
+	return NewBlockStoreWithBackend(NewMemoryBlockBackend())
+}
+
+func NewBlockStoreWithBackend(backend BlockBackend) *BlockStore {
 	return &BlockStore{
-		BlockMap: map[string]*Block{},
+		Backend: backend,
 	}
 }
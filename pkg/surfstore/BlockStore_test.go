@@ -0,0 +1,126 @@
+package surfstore
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	grpc "google.golang.org/grpc"
+)
+
+// how many blocks of how many bytes every benchmark iteration moves
+const BENCH_BLOCKS int = 256
+const BENCH_BLOCK_SIZE int = 4096
+
+// serveTest serves what register registers on a local port until the test ends and returns its address
+func serveTest(tb testing.TB, register func(grpcServer *grpc.Server)) string {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	register(grpcServer)
+	go grpcServer.Serve(listener)
+	tb.Cleanup(grpcServer.Stop)
+	return listener.Addr().String()
+}
+
+// startTestBlockStore serves an in-memory blockstore until the benchmark ends
+func startTestBlockStore(b *testing.B) string {
+	return serveTest(b, func(grpcServer *grpc.Server) {
+		RegisterBlockStoreServer(grpcServer, NewBlockStore())
+	})
+}
+
+func benchBlocks() ([]*Block, []string) {
+	blocks := []*Block{}
+	hashes := []string{}
+	for i := 0; i < BENCH_BLOCKS; i++ {
+		data := make([]byte, BENCH_BLOCK_SIZE)
+		copy(data, fmt.Sprintf("block %d", i))
+		hash := GetBlockHashString(data)
+		blocks = append(blocks, &Block{BlockData: data, BlockSize: int32(len(data)), Hash: hash})
+		hashes = append(hashes, hash)
+	}
+	return blocks, hashes
+}
+
+func BenchmarkPutBlocks(b *testing.B) {
+	addr := startTestBlockStore(b)
+	client := NewSurfstoreRPCClient(addr, b.TempDir(), BENCH_BLOCK_SIZE)
+	defer client.Close()
+	blocks, _ := benchBlocks()
+
+	b.SetBytes(int64(BENCH_BLOCKS * BENCH_BLOCK_SIZE))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var stored []string
+		if err := client.PutBlocks(blocks, addr, &stored); err != nil {
+			b.Fatal(err)
+		}
+		if len(stored) != BENCH_BLOCKS {
+			b.Fatalf("stored %d of %d blocks", len(stored), BENCH_BLOCKS)
+		}
+	}
+}
+
+// the same blocks with one unary call each, what the sync did before the streams
+func BenchmarkPutBlock(b *testing.B) {
+	addr := startTestBlockStore(b)
+	client := NewSurfstoreRPCClient(addr, b.TempDir(), BENCH_BLOCK_SIZE)
+	defer client.Close()
+	blocks, _ := benchBlocks()
+
+	b.SetBytes(int64(BENCH_BLOCKS * BENCH_BLOCK_SIZE))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, block := range blocks {
+			var succ bool
+			if err := client.PutBlock(block, addr, &succ); err != nil || !succ {
+				b.Fatalf("could not put block: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkGetBlocks(b *testing.B) {
+	addr := startTestBlockStore(b)
+	client := NewSurfstoreRPCClient(addr, b.TempDir(), BENCH_BLOCK_SIZE)
+	defer client.Close()
+	blocks, hashes := benchBlocks()
+	var stored []string
+	if err := client.PutBlocks(blocks, addr, &stored); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(BENCH_BLOCKS * BENCH_BLOCK_SIZE))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var got []*Block
+		if err := client.GetBlocks(hashes, addr, &got); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetBlock(b *testing.B) {
+	addr := startTestBlockStore(b)
+	client := NewSurfstoreRPCClient(addr, b.TempDir(), BENCH_BLOCK_SIZE)
+	defer client.Close()
+	blocks, hashes := benchBlocks()
+	var stored []string
+	if err := client.PutBlocks(blocks, addr, &stored); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(BENCH_BLOCKS * BENCH_BLOCK_SIZE))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, hash := range hashes {
+			var block Block
+			if err := client.GetBlock(hash, addr, &block); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
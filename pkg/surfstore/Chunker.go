@@ -0,0 +1,193 @@
+package surfstore
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+client side:
+a Chunker splits a file into the blocks we hash and upload. Fixed chunking cuts every BlockSize bytes, so inserting one
+byte at the start of a file shifts every block and changes every hash. Content-defined chunking (FastCDC) cuts where a
+rolling hash of the last bytes matches a pattern, so the cut points move with the content and an edit only changes the
+blocks around it. Every client syncing the same files has to use the same chunking and block size, otherwise they keep
+seeing each other's files as changed.
+*/
+
+type Chunker interface {
+	// Next returns the next block of the file, and io.EOF once there are no more
+	Next() ([]byte, error)
+}
+
+// The names accepted by NewChunker
+const FIXED_CHUNKING string = "fixed"
+const CDC_CHUNKING string = "cdc"
+
+// NewChunker splits r with the given chunking, "" means fixed. With content-defined chunking blockSize is the average size of a block.
+func NewChunker(chunking string, r io.Reader, blockSize int) (Chunker, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid block size %d", blockSize)
+	}
+	switch chunking {
+	case FIXED_CHUNKING, "":
+		return &FixedChunker{Reader: r, BlockSize: blockSize}, nil
+	case CDC_CHUNKING:
+		return NewCDCChunker(r, blockSize), nil
+	default:
+		return nil, fmt.Errorf("unknown chunking %q", chunking)
+	}
+}
+
+// maxBlockSize is the size of the largest block NewChunker makes with the given chunking and block size
+func maxBlockSize(chunking string, blockSize int) int {
+	if chunking == CDC_CHUNKING {
+		return blockSize * CDC_MAX_SIZE_MULTIPLIER
+	}
+	return blockSize
+}
+
+/* Fixed-size blocks */
+
+type FixedChunker struct {
+	Reader    io.Reader
+	BlockSize int
+}
+
+func (fc *FixedChunker) Next() ([]byte, error) {
+	block := make([]byte, fc.BlockSize)
+	length, err := io.ReadFull(fc.Reader, block) // the last block may be less than BlockSize
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if length == 0 && err == nil {
+		err = io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block[:length], nil
+}
+
+var _ Chunker = new(FixedChunker)
+
+/* Content-defined blocks (FastCDC) */
+
+// blocks are between a quarter and eight times the average size, like in the FastCDC paper
+const CDC_MIN_SIZE_DIVISOR int = 4
+const CDC_MAX_SIZE_MULTIPLIER int = 8
+
+// random values for every byte, generated from a fixed seed since every client must cut at the same places
+var gearTable = newGearTable(0x5375726653746f72) // "SurfStor"
+
+func newGearTable(seed uint64) [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}
+
+type CDCChunker struct {
+	Reader  io.Reader
+	MinSize int
+	AvgSize int
+	MaxSize int
+
+	// normalized chunking: a harder pattern before AvgSize and an easier one after it,
+	// so the sizes cluster around AvgSize. The masks test the top bits of the hash, which depend on the most bytes.
+	maskS uint64
+	maskL uint64
+
+	buf []byte // read but not yet returned
+	eof bool
+}
+
+func NewCDCChunker(r io.Reader, avgSize int) *CDCChunker {
+	minSize := avgSize / CDC_MIN_SIZE_DIVISOR
+	if minSize < 1 {
+		minSize = 1
+	}
+	bits := 0
+	for 1<<(bits+1) <= avgSize {
+		bits++
+	}
+	return &CDCChunker{
+		Reader:  r,
+		MinSize: minSize,
+		AvgSize: avgSize,
+		MaxSize: avgSize * CDC_MAX_SIZE_MULTIPLIER,
+		maskS:   topBitsMask(bits + 1),
+		maskL:   topBitsMask(bits - 1),
+	}
+}
+
+func topBitsMask(bits int) uint64 {
+	if bits <= 0 {
+		return 0
+	}
+	if bits >= 64 {
+		return ^uint64(0)
+	}
+	return ^uint64(0) << (64 - bits)
+}
+
+func (cc *CDCChunker) Next() ([]byte, error) {
+	// keep MaxSize bytes buffered, the cut point is always within them
+	for !cc.eof && len(cc.buf) < cc.MaxSize {
+		chunk := make([]byte, cc.MaxSize-len(cc.buf))
+		length, err := io.ReadFull(cc.Reader, chunk)
+		cc.buf = append(cc.buf, chunk[:length]...)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			cc.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if len(cc.buf) == 0 {
+		return nil, io.EOF
+	}
+
+	cut := cc.cutPoint(cc.buf)
+	block := make([]byte, cut)
+	copy(block, cc.buf[:cut])
+	cc.buf = cc.buf[cut:]
+	return block, nil
+}
+
+// cutPoint returns the length of the next block at the start of data
+func (cc *CDCChunker) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= cc.MinSize {
+		return n
+	}
+	if n > cc.MaxSize {
+		n = cc.MaxSize
+	}
+	normal := cc.AvgSize
+	if normal > n {
+		normal = n
+	}
+
+	var hash uint64 = 0
+	i := cc.MinSize
+	for ; i < normal; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&cc.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&cc.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+var _ Chunker = new(CDCChunker)
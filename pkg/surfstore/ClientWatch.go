@@ -0,0 +1,179 @@
+package surfstore
+
+import (
+	context "context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+/*
+client side:
+watch mode keeps the base directory in sync instead of syncing once. The filesystem tells us (through inotify) which paths
+changed, we wait until a burst of writes has been quiet for a while and then sync only those paths. Changes made by other
+clients are pushed by the metastore's WatchFiles stream, and in case that breaks the server is also polled every pollInterval.
+*/
+
+// how long to wait before reopening a broken WatchFiles stream
+const WATCH_RETRY_INTERVAL time.Duration = time.Second
+
+// ClientWatch does a full sync and then keeps the base directory in sync until stop is closed.
+// A sync that is running when stop is closed is finished, and the index written, before ClientWatch returns.
+func ClientWatch(client RPCClient, pollInterval time.Duration, debounce time.Duration, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// watch before the first sync, so nothing that changes while it runs is missed
+	if err := watchTree(watcher, client.BaseDir); err != nil {
+		return err
+	}
+	ClientSync(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remoteChanged := make(chan struct{}, 1)
+	go watchRemote(ctx, client, remoteChanged)
+
+	changed := make(map[string]bool) // paths changed since the last sync, relative to the base directory
+	fullScan := false                // we lost track of what changed, rescan everything
+	syncChanges := func() {
+		changedPaths := []string{}
+		for changedPath := range changed {
+			changedPaths = append(changedPaths, changedPath)
+		}
+		if fullScan {
+			changedPaths = nil
+		}
+		changed = make(map[string]bool)
+		fullScan = false
+
+		log.Println("[Watch] syncing", len(changedPaths), "changed paths")
+		clientSync(client, changedPaths)
+	}
+
+	debounceTimer := time.NewTimer(debounce)
+	debounceTimer.Stop()
+	defer debounceTimer.Stop()
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			fileName, ok := watchedFileName(client.BaseDir, event.Name)
+			if !ok {
+				continue
+			}
+			// inotify is not recursive, every new directory needs its own watch
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if fileInfo, err := os.Lstat(event.Name); err == nil && fileInfo.IsDir() {
+					if err := watchTree(watcher, event.Name); err != nil {
+						log.Println("[Watch] could not watch", event.Name, ":", err)
+						fullScan = true
+					}
+				}
+			}
+			changed[fileName] = true
+			debounceTimer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// most likely the event queue overflowed, so we don't know what changed anymore
+			log.Println("[Watch] watcher error:", err)
+			fullScan = true
+			debounceTimer.Reset(debounce)
+
+		case <-remoteChanged:
+			// another client changed something, debounced too since updates tend to come in bursts
+			debounceTimer.Reset(debounce)
+
+		case <-debounceTimer.C:
+			syncChanges()
+
+		case <-poll.C:
+			// changes still being debounced go along, they'd be synced right after anyway
+			syncChanges()
+		}
+	}
+}
+
+// watchRemote signals remoteChanged for every change the metastore pushes, reopening the stream where it left off
+// whenever it breaks (e.g. the leader changed), until ctx is cancelled
+func watchRemote(ctx context.Context, client RPCClient, remoteChanged chan<- struct{}) {
+	var cursor int64 = 0
+	epoch := ""
+	for {
+		err := client.WatchFiles(ctx, cursor, epoch, func(event *FileChangeEvent) error {
+			cursor = event.Seq
+			epoch = event.Epoch
+			select {
+			case remoteChanged <- struct{}{}:
+			default: // a sync is already coming
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		switch status.Code(err) {
+		case codes.Unimplemented: // an older metastore, polling will have to do
+			log.Println("[Watch] the metastore can't push changes, polling only")
+			return
+		case codes.OutOfRange: // the metastore lost its state, start over
+			cursor = 0
+			epoch = ""
+		}
+		log.Println("[Watch] change stream broke, reconnecting:", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(WATCH_RETRY_INTERVAL):
+		}
+	}
+}
+
+// watchTree adds a watch for root and every directory under it
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) { // removed before we got to it
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// watchedFileName turns the path of an event into the name the index uses, ok is false for paths that are not synced
+func watchedFileName(baseDir string, eventPath string) (string, bool) {
+	relPath, err := filepath.Rel(baseDir, eventPath)
+	if err != nil {
+		return "", false
+	}
+	fileName := filepath.ToSlash(relPath)
+	if fileName == "." || isMetaFile(fileName) {
+		return "", false
+	}
+	return fileName, true
+}
@@ -0,0 +1,76 @@
+package surfstore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+/*
+client side:
+the connection pool keeps one long-lived gRPC connection per server address, shared by every goroutine (and every copy)
+of the client. A grpc.ClientConn multiplexes concurrent calls over one HTTP/2 connection, so one per address is enough.
+Dialing doesn't wait for the connection, it is made on the first call, and grpc reconnects it on its own if it breaks.
+Keepalive pings find connections that died while the client was idle (e.g. during a long watch).
+*/
+
+// how often an idle connection is pinged, and how long the ping may take before the connection is considered dead.
+// the servers have to allow pings that often, see KEEPALIVE_MIN_TIME
+const KEEPALIVE_TIME time.Duration = 30 * time.Second
+const KEEPALIVE_TIMEOUT time.Duration = 10 * time.Second
+
+// server side: how often the servers let a client ping, by default grpc servers drop clients that ping more than every 5 minutes
+const KEEPALIVE_MIN_TIME time.Duration = 10 * time.Second
+
+type ConnPool struct {
+	mu     sync.Mutex
+	conns  map[string]*grpc.ClientConn // addr : connection
+	closed bool
+}
+
+func NewConnPool() *ConnPool {
+	return &ConnPool{
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Get returns the connection to addr, dialing it the first time. The connection belongs to the pool, don't close it.
+func (p *ConnPool) Get(addr string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("connection pool is closed")
+	}
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                KEEPALIVE_TIME,
+		Timeout:             KEEPALIVE_TIMEOUT,
+		PermitWithoutStream: true,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// Close closes every connection, calls in flight fail and Get fails from now on
+func (p *ConnPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var firstErr error
+	for addr, conn := range p.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(p.conns, addr)
+	}
+	return firstErr
+}
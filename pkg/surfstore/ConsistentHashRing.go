@@ -3,35 +3,100 @@ package surfstore
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 )
 
+/*
+Every blockstore is placed on the ring at virtualNodes * weight points (its virtual nodes), so the blocks are spread
+evenly even with few servers, and a server with weight 2 owns about twice as many blocks as a server with weight 1.
+Every block is stored on replicationFactor servers: the owner and the next distinct servers clockwise on the ring.
+Lookups binary search a sorted slice of the virtual node hashes that is built once when the ring is created. A ring is
+never changed after it is created (its fields are only set by the constructors, WithServer/WithoutServer return a
+new one), so it can be shared between goroutines without locking and a membership change just swaps the pointer.
+A ring with virtualNodes 0 places every server at the single point Hash("blockstore"+addr), as the ring did before
+virtual nodes existed, so a deployment that gives no -vnodes and no weights keeps its blocks where they are.
+Giving any server a weight other than 1 needs virtual nodes. Turning them on moves most blocks, so it is never done
+implicitly, a weight on a ring without virtual nodes is an error and the operator has to pass -vnodes.
+Every ring has a version, a ring made from another one by WithServer/WithoutServer has the next one. The metastore
+refuses an UpdateFile whose blocks were placed with an older ring than the current one.
+*/
+
 type ConsistentHashRing struct {
-	ServerMap map[string]string
+	serverMap    map[string]string // hash of a virtual node : servername
+	weights      map[string]int    // servername : weight
+	virtualNodes int               // virtual nodes per unit of weight, 0 for the legacy one point per server
+	// number of distinct servers every block is stored on
+	replicationFactor int
+	version           int64 // 1 for a ring from the command line, +1 for every membership change
+
+	tokens []string // the keys of serverMap, sorted
+}
+
+func sortTokens(serverMap map[string]string) []string {
+	tokens := make([]string, 0, len(serverMap))
+	for hash := range serverMap {
+		tokens = append(tokens, hash)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// index in tokens of the first virtual node strictly after blockId, wrapping around to 0 past the tail
+func successor(tokens []string, blockId string) int {
+	i := sort.Search(len(tokens), func(i int) bool { return tokens[i] > blockId })
+	if i == len(tokens) {
+		return 0
+	}
+	return i
 }
 
 func (c ConsistentHashRing) GetResponsibleServer(blockId string) string {
-	// blockHash := c.Hash(blockId) //?
+	tokens := c.tokens
+	if len(tokens) == 0 {
+		return ""
+	}
+	// if no responsible server, which means it's in the tail, successor wraps around to the first one
+	return c.serverMap[tokens[successor(tokens, blockId)]]
+}
 
-	// sorted by hash
-	hashes := []string{}
-	for hash := range c.ServerMap {
-		hashes = append(hashes, hash)
+// GetResponsibleServers returns the replicationFactor successive distinct servers clockwise from the block,
+// the first one is the server GetResponsibleServer returns
+func (c ConsistentHashRing) GetResponsibleServers(blockId string) []string {
+	tokens := c.tokens
+	if len(tokens) == 0 {
+		return []string{}
 	}
-	sort.Strings(hashes)
 
-	responsibleSever := ""
-	for i := 0; i < len(hashes); i++ {
-		if hashes[i] > blockId {
-			responsibleSever = c.ServerMap[hashes[i]]
-			break
-		}
+	replicas := c.replicationFactor
+	if replicas > len(c.weights) {
+		replicas = len(c.weights)
 	}
-	if responsibleSever == "" { // if no responsible server, which means it's in the tail, return the first one
-		responsibleSever = c.ServerMap[hashes[0]]
+	if replicas < 1 {
+		replicas = 1
 	}
 
-	return responsibleSever
+	start := successor(tokens, blockId)
+	servers := make([]string, 0, replicas)
+	for i := 0; i < len(tokens) && len(servers) < replicas; i++ {
+		server := c.serverMap[tokens[(start+i)%len(tokens)]]
+		if !containsString(servers, server) {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// replica sets are tiny, a linear scan beats allocating a map on every lookup
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 func (c ConsistentHashRing) Hash(addr string) string {
@@ -41,14 +106,166 @@ func (c ConsistentHashRing) Hash(addr string) string {
 
 }
 
+// GetServerAddrs returns every server on the ring, sorted
+func (c ConsistentHashRing) GetServerAddrs() []string {
+	addrs := []string{}
+	for addr := range c.weights {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// the name hashed for the i-th virtual node of a server
+func virtualNodeName(addr string, i int) string {
+	return "blockstore" + addr + "#" + strconv.Itoa(i)
+}
+
+// ParseBlockStoreArgs splits the blockstore arguments of the server, each either "addr" or "addr=weight",
+// into the addresses and their weights (1 if not given).
+func ParseBlockStoreArgs(args []string) ([]string, map[string]int, error) {
+	addrs := []string{}
+	weights := make(map[string]int)
+	for _, arg := range args {
+		addr, weight := arg, 1
+		if i := strings.LastIndex(arg, "="); i >= 0 {
+			w, err := strconv.Atoi(arg[i+1:])
+			if err != nil || w <= 0 {
+				return nil, nil, fmt.Errorf("invalid weight in %q, expected addr=weight with a positive weight", arg)
+			}
+			addr, weight = arg[:i], w
+		}
+		if _, ok := weights[addr]; ok {
+			return nil, nil, fmt.Errorf("blockstore %s is listed twice", addr)
+		}
+		addrs = append(addrs, addr)
+		weights[addr] = weight
+	}
+	return addrs, weights, nil
+}
+
+// NewConsistentHashRing builds the legacy ring with one point per server and no replication
 func NewConsistentHashRing(serverAddrs []string) *ConsistentHashRing {
+	c, _ := NewWeightedConsistentHashRing(serverAddrs, nil, 0, 1) // no weights, can't fail
+	return c
+}
+
+// NewWeightedConsistentHashRing places every server at virtualNodes * weight points of the ring,
+// servers missing from weights get weight 1. virtualNodes 0 keeps the legacy placement, where every weight must be 1.
+// Every block is stored on replicationFactor servers (at least 1, at most all of them).
+func NewWeightedConsistentHashRing(serverAddrs []string, weights map[string]int, virtualNodes int, replicationFactor int) (*ConsistentHashRing, error) {
+	if virtualNodes < 0 {
+		virtualNodes = 0
+	}
+	if virtualNodes == 0 {
+		for _, addr := range serverAddrs {
+			if weight, ok := weights[addr]; ok && weight != 1 {
+				return nil, fmt.Errorf("blockstore %s has weight %d but the ring has no virtual nodes, pass -vnodes to use weights (this moves most blocks)", addr, weight)
+			}
+		}
+	}
 	c := &ConsistentHashRing{
-		ServerMap: make(map[string]string), // hash : servername
+		serverMap:         make(map[string]string), // hash : servername
+		weights:           make(map[string]int),
+		virtualNodes:      virtualNodes,
+		replicationFactor: replicationFactor,
+		version:           1,
 	}
 
 	for _, addr := range serverAddrs {
-		c.ServerMap[c.Hash("blockstore"+addr)] = addr
+		weight, ok := weights[addr]
+		if !ok || weight <= 0 {
+			weight = 1
+		}
+		c.weights[addr] = weight
+		if virtualNodes == 0 {
+			c.serverMap[c.Hash("blockstore"+addr)] = addr
+			continue
+		}
+		for i := 0; i < virtualNodes*weight; i++ {
+			c.serverMap[c.Hash(virtualNodeName(addr, i))] = addr
+		}
 	}
+	c.tokens = sortTokens(c.serverMap)
 
-	return c
+	return c, nil
+}
+
+// WithServer returns a new ring with addr added, the ring itself is never changed
+func (c ConsistentHashRing) WithServer(addr string, weight int) (*ConsistentHashRing, error) {
+	if _, ok := c.weights[addr]; ok {
+		return nil, fmt.Errorf("blockstore %s is already on the ring", addr)
+	}
+	weights := make(map[string]int)
+	for server, w := range c.weights {
+		weights[server] = w
+	}
+	weights[addr] = weight
+	return c.withWeights(weights)
+}
+
+// WithoutServer returns a new ring with addr removed, the ring itself is never changed
+func (c ConsistentHashRing) WithoutServer(addr string) (*ConsistentHashRing, error) {
+	if _, ok := c.weights[addr]; !ok {
+		return nil, fmt.Errorf("blockstore %s is not on the ring", addr)
+	}
+	if len(c.weights) == 1 {
+		return nil, fmt.Errorf("cannot remove the last blockstore %s", addr)
+	}
+	weights := make(map[string]int)
+	for server, w := range c.weights {
+		if server != addr {
+			weights[server] = w
+		}
+	}
+	return c.withWeights(weights)
+}
+
+func (c ConsistentHashRing) withWeights(weights map[string]int) (*ConsistentHashRing, error) {
+	addrs := []string{}
+	for addr := range weights {
+		addrs = append(addrs, addr)
+	}
+	ring, err := NewWeightedConsistentHashRing(addrs, weights, c.virtualNodes, c.replicationFactor)
+	if err != nil {
+		return nil, err
+	}
+	ring.version = c.version + 1
+	return ring, nil
+}
+
+// GetVersion returns the version of the ring, it goes up with every membership change
+func (c ConsistentHashRing) GetVersion() int64 {
+	return c.version
+}
+
+// ToProto captures the ring's configuration so it can be logged and replicated
+func (c ConsistentHashRing) ToProto() *BlockStoreRing {
+	weights := make(map[string]int32)
+	for addr, weight := range c.weights {
+		weights[addr] = int32(weight)
+	}
+	return &BlockStoreRing{
+		Weights:           weights,
+		VirtualNodes:      int32(c.virtualNodes),
+		ReplicationFactor: int32(c.replicationFactor),
+		Version:           c.version,
+	}
+}
+
+func NewConsistentHashRingFromProto(blockStoreRing *BlockStoreRing) (*ConsistentHashRing, error) {
+	addrs := []string{}
+	weights := make(map[string]int)
+	for addr, weight := range blockStoreRing.Weights {
+		addrs = append(addrs, addr)
+		weights[addr] = int(weight)
+	}
+	ring, err := NewWeightedConsistentHashRing(addrs, weights, int(blockStoreRing.VirtualNodes), int(blockStoreRing.ReplicationFactor))
+	if err != nil {
+		return nil, err
+	}
+	if blockStoreRing.Version > ring.version { // rings logged before versions existed have none
+		ring.version = blockStoreRing.Version
+	}
+	return ring, nil
 }
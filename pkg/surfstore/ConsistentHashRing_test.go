@@ -0,0 +1,175 @@
+package surfstore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+const TEST_RING_BLOCKS int = 100000
+
+func testRingServers(n int) []string {
+	addrs := []string{}
+	for i := 0; i < n; i++ {
+		addrs = append(addrs, fmt.Sprintf("localhost:%d", 8081+i))
+	}
+	return addrs
+}
+
+// share of TEST_RING_BLOCKS block hashes every server owns
+func ringShares(ring *ConsistentHashRing) map[string]float64 {
+	counts := make(map[string]int)
+	for i := 0; i < TEST_RING_BLOCKS; i++ {
+		counts[ring.GetResponsibleServer(GetBlockHashString([]byte(fmt.Sprintf("block %d", i))))]++
+	}
+	shares := make(map[string]float64)
+	for addr, count := range counts {
+		shares[addr] = float64(count) / float64(TEST_RING_BLOCKS)
+	}
+	return shares
+}
+
+// without vnodes and weights a block must go where the ring put it before virtual nodes existed:
+// the first server whose Hash("blockstore"+addr) is after the block, wrapping around
+func TestConsistentHashRingLegacyPlacement(t *testing.T) {
+	addrs := testRingServers(5)
+	ring := NewConsistentHashRing(addrs)
+
+	serverHashes := []string{}
+	servers := make(map[string]string)
+	for _, addr := range addrs {
+		hash := ring.Hash("blockstore" + addr)
+		serverHashes = append(serverHashes, hash)
+		servers[hash] = addr
+	}
+	sort.Strings(serverHashes)
+
+	for i := 0; i < 1000; i++ {
+		blockHash := GetBlockHashString([]byte(fmt.Sprintf("block %d", i)))
+		want := servers[serverHashes[0]]
+		for _, hash := range serverHashes {
+			if hash > blockHash {
+				want = servers[hash]
+				break
+			}
+		}
+		if got := ring.GetResponsibleServer(blockHash); got != want {
+			t.Fatalf("block %s is on %s, the legacy ring puts it on %s", blockHash, got, want)
+		}
+	}
+
+	// a ring restored from its proto keeps the placement
+	restored, err := NewConsistentHashRingFromProto(ring.ToProto())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(restored.serverMap) != fmt.Sprint(ring.serverMap) {
+		t.Errorf("restored ring has different points")
+	}
+
+	// every membership change gives the ring a new version, which survives the proto
+	added, err := ring.WithServer("localhost:9999", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.GetVersion() != ring.GetVersion()+1 {
+		t.Errorf("ring version went from %d to %d", ring.GetVersion(), added.GetVersion())
+	}
+	restored, err = NewConsistentHashRingFromProto(added.ToProto())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetVersion() != added.GetVersion() {
+		t.Errorf("restored ring has version %d, expected %d", restored.GetVersion(), added.GetVersion())
+	}
+}
+
+func TestConsistentHashRingEvenShares(t *testing.T) {
+	addrs := testRingServers(8)
+	ring, err := NewWeightedConsistentHashRing(addrs, nil, DEFAULT_VIRTUAL_NODES, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := ringShares(ring)
+
+	want := 1 / float64(len(addrs))
+	for _, addr := range addrs {
+		// with 100 virtual nodes every server ends up within a third of its fair share
+		if math.Abs(shares[addr]-want) > want/3 {
+			t.Errorf("%s owns %.3f of the blocks, expected about %.3f", addr, shares[addr], want)
+		}
+	}
+}
+
+func TestConsistentHashRingWeights(t *testing.T) {
+	addrs := testRingServers(4)
+	weights := map[string]int{addrs[0]: 1, addrs[1]: 1, addrs[2]: 2, addrs[3]: 4}
+	totalWeight := 8
+
+	ring, err := NewWeightedConsistentHashRing(addrs, weights, DEFAULT_VIRTUAL_NODES, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := ringShares(ring)
+	for _, addr := range addrs {
+		want := float64(weights[addr]) / float64(totalWeight)
+		if math.Abs(shares[addr]-want) > want/3 {
+			t.Errorf("%s with weight %d owns %.3f of the blocks, expected about %.3f", addr, weights[addr], shares[addr], want)
+		}
+	}
+
+	// weights never turn on virtual nodes behind the operator's back, that would move most blocks
+	if _, err := NewWeightedConsistentHashRing(addrs, weights, 0, 1); err == nil {
+		t.Errorf("weighted ring without virtual nodes was built")
+	}
+	legacy := NewConsistentHashRing(addrs[:2])
+	if _, err := legacy.WithServer(addrs[2], 2); err == nil {
+		t.Errorf("server with weight 2 was added to a ring without virtual nodes")
+	}
+	added, err := legacy.WithServer(addrs[2], 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.virtualNodes != 0 {
+		t.Errorf("adding a server changed the ring to %d virtual nodes", added.virtualNodes)
+	}
+}
+
+func TestConsistentHashRingReplicas(t *testing.T) {
+	ring, err := NewWeightedConsistentHashRing(testRingServers(5), nil, DEFAULT_VIRTUAL_NODES, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		blockHash := GetBlockHashString([]byte(fmt.Sprintf("block %d", i)))
+		servers := ring.GetResponsibleServers(blockHash)
+		if len(servers) != 3 {
+			t.Fatalf("block %s has %d replicas, expected 3", blockHash, len(servers))
+		}
+		if servers[0] != ring.GetResponsibleServer(blockHash) {
+			t.Errorf("first replica of %s is %s, the owner is %s", blockHash, servers[0], ring.GetResponsibleServer(blockHash))
+		}
+		if servers[0] == servers[1] || servers[0] == servers[2] || servers[1] == servers[2] {
+			t.Errorf("replicas of %s are not distinct: %v", blockHash, servers)
+		}
+	}
+}
+
+func BenchmarkGetResponsibleServers(b *testing.B) {
+	hashes := make([]string, 10000)
+	for i := range hashes {
+		hashes[i] = GetBlockHashString([]byte(fmt.Sprintf("block %d", i)))
+	}
+	ring, err := NewWeightedConsistentHashRing(testRingServers(16), nil, DEFAULT_VIRTUAL_NODES, 3)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, hash := range hashes {
+			ring.GetResponsibleServers(hash)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package surfstore
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/tink/go/daead/subtle"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+/*
+client side:
+end-to-end encryption, the blockstores only ever see encrypted blocks and the metastore only encrypted filenames.
+Both are encrypted deterministically with AES-SIV (RFC 5297): the same content always encrypts to the same block, so
+clients sharing the key still deduplicate, and the same name always encrypts to the same filename, so the metastore
+can still key files by name. AES-SIV authenticates what it decrypts, and it stays safe when a plaintext repeats, it
+only reveals that it repeated. The implementation is Tink's (daead/subtle).
+Block hashes are computed over the encrypted blocks, so the servers don't learn the hashes of the plaintext either.
+
+A passphrase is stretched with Argon2id. The salt is random, created by the first client that encrypts and kept by the
+metastore (GetEncryptionSalt/SetEncryptionSalt), so every client of the same metastore derives the same key from the
+same passphrase, but a passphrase can't be attacked with tables computed for another deployment.
+*/
+
+// Argon2id parameters for passphrases (the second recommended option of RFC 9106), memory is in KiB
+const PASSPHRASE_TIME uint32 = 3
+const PASSPHRASE_MEMORY uint32 = 64 * 1024
+const PASSPHRASE_THREADS uint8 = 4
+const PASSPHRASE_SALT_SIZE int = 16
+
+const KEY_SIZE int = 32
+
+type Crypter struct {
+	blockSIV *subtle.AESSIV
+	nameSIV  *subtle.AESSIV
+}
+
+// NewCrypterFromPassphrase derives the key from a passphrase and the salt of the metastore (see RPCClient.GetEncryptionSalt)
+func NewCrypterFromPassphrase(passphrase string, salt []byte) (*Crypter, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	if len(salt) < PASSPHRASE_SALT_SIZE {
+		return nil, fmt.Errorf("passphrase salt is too short")
+	}
+	return NewCrypter(argon2.IDKey([]byte(passphrase), salt, PASSPHRASE_TIME, PASSPHRASE_MEMORY, PASSPHRASE_THREADS, uint32(KEY_SIZE)))
+}
+
+// NewCrypterFromKeyFile derives the key from the contents of a file, which should hold at least 32 random bytes
+func NewCrypterFromKeyFile(path string) (*Crypter, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) < KEY_SIZE {
+		return nil, fmt.Errorf("key file %s is too short, it needs at least %d random bytes", path, KEY_SIZE)
+	}
+	key := sha256.Sum256(contents)
+	return NewCrypter(key[:])
+}
+
+// NewCrypter derives separate keys for blocks and filenames from the master key
+func NewCrypter(masterKey []byte) (*Crypter, error) {
+	blockSIV, err := newSIV(masterKey, "block encryption")
+	if err != nil {
+		return nil, err
+	}
+	nameSIV, err := newSIV(masterKey, "filename encryption")
+	if err != nil {
+		return nil, err
+	}
+	return &Crypter{blockSIV: blockSIV, nameSIV: nameSIV}, nil
+}
+
+// SealBlock encrypts the data of a block
+func (c *Crypter) SealBlock(plaintext []byte) []byte {
+	sealed, err := c.blockSIV.EncryptDeterministically(plaintext, nil)
+	if err != nil { // only for plaintexts of about 2GB, no block gets near that
+		panic(err)
+	}
+	return sealed
+}
+
+// OpenBlock decrypts and authenticates a block sealed by SealBlock
+func (c *Crypter) OpenBlock(sealed []byte) ([]byte, error) {
+	plaintext, err := c.blockSIV.DecryptDeterministically(sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt block: %v", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptFilename encrypts a (relative) path as a whole, the result has no '/' or ','
+func (c *Crypter) EncryptFilename(filename string) string {
+	sealed, err := c.nameSIV.EncryptDeterministically([]byte(filename), nil)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+func (c *Crypter) DecryptFilename(encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt filename %q: not encrypted", encrypted)
+	}
+	filename, err := c.nameSIV.DecryptDeterministically(sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt filename %q: %v", encrypted, err)
+	}
+	return string(filename), nil
+}
+
+// newSIV derives an AES-SIV key for one purpose from the master key with HKDF
+func newSIV(masterKey []byte, purpose string) (*subtle.AESSIV, error) {
+	key := make([]byte, subtle.AESSIVKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, []byte(purpose)), key); err != nil {
+		return nil, err
+	}
+	return subtle.NewAESSIV(key)
+}
@@ -0,0 +1,65 @@
+package surfstore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCrypterRoundTrip(t *testing.T) {
+	crypter, err := NewCrypter(bytes.Repeat([]byte{7}, KEY_SIZE))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := []byte("some block of a file")
+	sealed := crypter.SealBlock(block)
+	if !bytes.Equal(sealed, crypter.SealBlock(block)) {
+		t.Errorf("sealing a block is not deterministic, clients would not deduplicate")
+	}
+	opened, err := crypter.OpenBlock(sealed)
+	if err != nil || !bytes.Equal(opened, block) {
+		t.Fatalf("could not open a sealed block: %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := crypter.OpenBlock(sealed); err == nil {
+		t.Errorf("a tampered block was opened")
+	}
+
+	filename := "dir/some, file.txt"
+	encrypted := crypter.EncryptFilename(filename)
+	if strings.ContainsAny(encrypted, "/,") {
+		t.Errorf("encrypted filename %q has a '/' or ','", encrypted)
+	}
+	// blocks and names use different keys
+	if encrypted == crypter.EncryptFilename(string(block)) {
+		t.Errorf("different names encrypt the same")
+	}
+	if decrypted, err := crypter.DecryptFilename(encrypted); err != nil || decrypted != filename {
+		t.Errorf("decrypted %q (%v), expected %q", decrypted, err, filename)
+	}
+	if _, err := crypter.DecryptFilename("a.txt"); err == nil {
+		t.Errorf("a plain filename was decrypted")
+	}
+}
+
+func TestCrypterPassphraseSalt(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, PASSPHRASE_SALT_SIZE)
+	otherSalt := bytes.Repeat([]byte{2}, PASSPHRASE_SALT_SIZE)
+
+	a, err := NewCrypterFromPassphrase("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewCrypterFromPassphrase("correct horse", salt)
+	c, _ := NewCrypterFromPassphrase("correct horse", otherSalt)
+	if a.EncryptFilename("a.txt") != b.EncryptFilename("a.txt") {
+		t.Errorf("the same passphrase and salt gave different keys")
+	}
+	if a.EncryptFilename("a.txt") == c.EncryptFilename("a.txt") {
+		t.Errorf("the same passphrase with another salt gave the same key")
+	}
+	if _, err := NewCrypterFromPassphrase("correct horse", nil); err == nil {
+		t.Errorf("a missing salt was accepted")
+	}
+}
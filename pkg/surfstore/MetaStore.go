@@ -2,8 +2,15 @@ package surfstore
 
 import (
 	context "context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"log"
+	"sort"
+	"sync"
 
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -14,17 +21,42 @@ This is the API provied by the server side, for example, this can be provided by
 we can just use this API and get result.
 */
 
+// UpdateFile got blocks placed with a ring that was replaced since, they may be missing on the new owners
+var ERR_STALE_RING = status.Error(codes.Aborted, "the blockstore ring changed since the blocks were placed, get the block map again")
+
+func IsStaleRing(err error) bool {
+	return status.Code(err) == codes.Aborted
+}
+
 type MetaStore struct {
+	mu                 sync.RWMutex // guards every field below, gRPC calls the handlers concurrently
 	FileMetaMap        map[string]*FileMetaData
 	BlockStoreAddrs    []string
-	ConsistentHashRing *ConsistentHashRing
+	ConsistentHashRing *ConsistentHashRing // never modified in place, membership changes swap in a new ring
+	WAL                *MetaWAL            // nil if the metastore is not durable
+	ringChanged        bool                // the ring no longer comes from the command line, so it has to be snapshotted
+	seq                int64               // number of accepted updates, the cursor of WatchFiles
+	epoch              string              // changes whenever seq starts over, so a cursor of another epoch is refused
+	fileSeqs           map[string]int64    // filename : seq of its last update
+	updated            chan struct{}       // closed (and replaced) on every accepted update, wakes up the watchers
+	encryptionSalt     []byte              // the passphrase salt of the encrypting clients, nil until one sets it
+
+	membershipMu sync.Mutex // only one blockstore is added or removed at a time
 	UnimplementedMetaStoreServer
 }
 
 // Returns a mapping of the files stored in the SurfStore cloud service,
 // including the version, filename, and hashlist.
 func (m *MetaStore) GetFileInfoMap(ctx context.Context, _ *emptypb.Empty) (*FileInfoMap, error) {
-	return &FileInfoMap{FileInfoMap: m.FileMetaMap}, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// the response is marshaled after we return, so hand out a copy rather than the map UpdateFile writes to
+	fileInfoMap := make(map[string]*FileMetaData, len(m.FileMetaMap))
+	for filename, fileMetaData := range m.FileMetaMap {
+		fileInfoMap[filename] = fileMetaData
+	}
+	return &FileInfoMap{FileInfoMap: fileInfoMap}, nil
 }
 
 // Updates the FileInfo values associated with a file stored in the cloud.
@@ -32,45 +64,355 @@ func (m *MetaStore) GetFileInfoMap(ctx context.Context, _ *emptypb.Empty) (*File
 // only if the new version number is exactly one greater than the current version number.
 // 2）Otherwise, you can send version=-1 to the client telling them that the version
 // they are trying to store is not right (likely too old).
+// 3) The blocks have to be placed with the current ring: a client that got its block map before a membership change
+// may have put them on servers that no longer own them (after the rebalancer's last pass), it gets ERR_STALE_RING.
 func (m *MetaStore) UpdateFile(ctx context.Context, fileMetaData *FileMetaData) (*Version, error) {
 	fmt.Println("start updatefile")
 	// MetaStore is in the server side, we need to update it according to fileMetaData in the client side
-	filename := fileMetaData.Filename         // need to check
-	version := fileMetaData.Version           // need to check
+	filename := fileMetaData.Filename // need to check
+	version := fileMetaData.Version   // need to check
+
+	// the version check and the write must happen atomically, otherwise two clients can both pass the check
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fileMetaData.RingVersion != 0 && fileMetaData.RingVersion != m.ConsistentHashRing.GetVersion() {
+		return nil, ERR_STALE_RING
+	}
+	// the ring version only matters for this call, it isn't part of the file's metadata
+	fileMetaData = &FileMetaData{Filename: filename, Version: version, BlockHashList: fileMetaData.BlockHashList}
 	if _, ok := m.FileMetaMap[filename]; ok { // can find the file in the map
-		if version-1 == m.FileMetaMap[filename].Version { // replace the hash list
-			m.FileMetaMap[filename] = fileMetaData
-		} else {
-			version = -1
+		if version-1 != m.FileMetaMap[filename].Version {
+			return &Version{Version: -1}, nil
 		}
-	} else { // cannot find the file in the map ==> create a new one
-		m.FileMetaMap[filename] = fileMetaData
+	}
+
+	// the update is accepted: log it before touching the map, so an acknowledged version is never lost
+	if err := m.logEntry(&MetaLogEntry{FileMetaData: fileMetaData}); err != nil {
+		return nil, err
+	}
+	m.FileMetaMap[filename] = fileMetaData // replace the hash list, or create a new one
+	m.seq++
+	m.fileSeqs[filename] = m.seq
+	close(m.updated)
+	m.updated = make(chan struct{})
+	if err := m.maybeSnapshot(); err != nil {
+		fmt.Println("Could not snapshot metastore: ", err)
 	}
 	return &Version{Version: version}, nil
 }
 
+// must hold m.mu
+func (m *MetaStore) logEntry(entry *MetaLogEntry) error {
+	if m.WAL == nil {
+		return nil
+	}
+	return m.WAL.Append(entry)
+}
+
+// a failed snapshot is not fatal, the log still holds every update and we try again on the next one, must hold m.mu
+func (m *MetaStore) maybeSnapshot() error {
+	if m.WAL == nil || !m.WAL.NeedsSnapshot() {
+		return nil
+	}
+	state := &MetaSnapshot{FileInfoMap: m.FileMetaMap, Seq: m.seq, FileSeqs: m.fileSeqs, Epoch: m.epoch, EncryptionSalt: m.encryptionSalt}
+	if m.ringChanged {
+		state.BlockStoreRing = m.ConsistentHashRing.ToProto()
+	}
+	return m.WAL.Snapshot(state)
+}
+
+// snapshotState captures the state for a raft snapshot. The maps are copied, the metastore keeps changing its own
+// while the snapshot is written out or sent to a follower.
+func (m *MetaStore) snapshotState() *MetaSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fileInfoMap := make(map[string]*FileMetaData, len(m.FileMetaMap))
+	for filename, fileMetaData := range m.FileMetaMap {
+		fileInfoMap[filename] = fileMetaData
+	}
+	fileSeqs := make(map[string]int64, len(m.fileSeqs))
+	for filename, seq := range m.fileSeqs {
+		fileSeqs[filename] = seq
+	}
+	state := &MetaSnapshot{FileInfoMap: fileInfoMap, Seq: m.seq, FileSeqs: fileSeqs, Epoch: m.epoch, EncryptionSalt: m.encryptionSalt}
+	if m.ringChanged {
+		state.BlockStoreRing = m.ConsistentHashRing.ToProto()
+	}
+	return state
+}
+
+// restoreSnapshot replaces the whole state with a raft snapshot, the snapshot itself is left alone
+func (m *MetaStore) restoreSnapshot(state *MetaSnapshot) error {
+	var ring *ConsistentHashRing
+	if state.BlockStoreRing != nil {
+		var err error
+		if ring, err = NewConsistentHashRingFromProto(state.BlockStoreRing); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.FileMetaMap = make(map[string]*FileMetaData, len(state.FileInfoMap))
+	for filename, fileMetaData := range state.FileInfoMap {
+		m.FileMetaMap[filename] = fileMetaData
+	}
+	m.fileSeqs = make(map[string]int64, len(state.FileSeqs))
+	for filename, seq := range state.FileSeqs {
+		m.fileSeqs[filename] = seq
+	}
+	m.seq = state.Seq
+	m.epoch = state.Epoch
+	m.encryptionSalt = state.EncryptionSalt
+	if ring != nil {
+		m.ConsistentHashRing = ring
+		m.BlockStoreAddrs = ring.GetServerAddrs()
+		m.ringChanged = true
+	}
+	close(m.updated)
+	m.updated = make(chan struct{})
+	return nil
+}
+
+// Streams the current state of every file changed after the request's sinceSeq, then every change as it is accepted,
+// until the client goes away. Only the latest change of each file is sent, so a client resuming from the seq of the
+// last event it got ends up with exactly the changes it missed.
+func (m *MetaStore) WatchFiles(watchRequest *WatchRequest, stream MetaStore_WatchFilesServer) error {
+	cursor := watchRequest.SinceSeq
+	for {
+		m.mu.RLock()
+		if err := m.checkCursor(cursor, watchRequest.Epoch); err != nil {
+			m.mu.RUnlock()
+			return err
+		}
+		events := m.changesSince(cursor)
+		updated := m.updated
+		m.mu.RUnlock()
+
+		for _, event := range events {
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+			cursor = event.Seq
+		}
+
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// the latest change of every file updated after seq, in seq order, must hold m.mu
+func (m *MetaStore) changesSince(seq int64) []*FileChangeEvent {
+	events := []*FileChangeEvent{}
+	for _, filename := range m.filesChangedSince(seq) {
+		fileMetaData := m.FileMetaMap[filename]
+		events = append(events, &FileChangeEvent{
+			Seq:      m.fileSeqs[filename],
+			Filename: filename,
+			Version:  fileMetaData.Version,
+			Deleted:  len(fileMetaData.BlockHashList) == 1 && fileMetaData.BlockHashList[0] == TOMBSTONE_HASHVALUE,
+			Epoch:    m.epoch,
+		})
+	}
+	return events
+}
+
+// the files updated after seq, ordered by their last update, must hold m.mu
+func (m *MetaStore) filesChangedSince(seq int64) []string {
+	filenames := []string{}
+	for filename, fileSeq := range m.fileSeqs {
+		if fileSeq > seq {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Slice(filenames, func(i, j int) bool { return m.fileSeqs[filenames[i]] < m.fileSeqs[filenames[j]] })
+	return filenames
+}
+
+// the metastore lost its state (e.g. it is not durable and restarted) if the client's cursor is from another epoch,
+// or ahead of us, so the client has to start over. must hold m.mu
+func (m *MetaStore) checkCursor(seq int64, epoch string) error {
+	if epoch != "" && epoch != m.epoch {
+		return status.Errorf(codes.OutOfRange, "seq %d is from epoch %s, the metastore is in epoch %s, resync from 0", seq, epoch, m.epoch)
+	}
+	if seq > m.seq {
+		return status.Errorf(codes.OutOfRange, "seq %d is ahead of the metastore (at %d), resync from 0", seq, m.seq)
+	}
+	return nil
+}
+
+// setEpoch is how a raft metastore takes the epoch of the log it applies
+func (m *MetaStore) setEpoch(epoch string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logEntry(&MetaLogEntry{Epoch: epoch}); err != nil {
+		return err
+	}
+	m.epoch = epoch
+	return nil
+}
+
+// a random id for a new history of seqs
+func newEpoch() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+// Returns the metadata of the files changed after the request's sinceSeq, oldest change first and at most limit
+// (capped at CHANGES_PAGE_SIZE) of them. The returned seq is the sinceSeq of the next page, or once there are
+// no more changes, the cursor to start from on the next sync.
+func (m *MetaStore) GetChangesSince(ctx context.Context, changesRequest *ChangesRequest) (*FileChanges, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := m.checkCursor(changesRequest.SinceSeq, changesRequest.Epoch); err != nil {
+		return nil, err
+	}
+	limit := int(changesRequest.Limit)
+	if limit <= 0 || limit > CHANGES_PAGE_SIZE {
+		limit = CHANGES_PAGE_SIZE
+	}
+
+	filenames := m.filesChangedSince(changesRequest.SinceSeq)
+	if len(filenames) > limit {
+		page := filenames[:limit]
+		fileChanges := &FileChanges{Seq: m.fileSeqs[page[len(page)-1]], More: true, Epoch: m.epoch}
+		for _, filename := range page {
+			fileChanges.FileMetaData = append(fileChanges.FileMetaData, m.FileMetaMap[filename])
+		}
+		return fileChanges, nil
+	}
+
+	fileChanges := &FileChanges{Seq: m.seq, More: false, Epoch: m.epoch}
+	for _, filename := range filenames {
+		fileChanges.FileMetaData = append(fileChanges.FileMetaData, m.FileMetaMap[filename])
+	}
+	return fileChanges, nil
+}
+
+// Returns the salt the encrypting clients derive the key of a passphrase with, empty if none was set yet.
+func (m *MetaStore) GetEncryptionSalt(ctx context.Context, _ *emptypb.Empty) (*EncryptionSalt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &EncryptionSalt{Salt: m.encryptionSalt}, nil
+}
+
+// Sets the salt, unless a client already did. Returns the salt in effect, so clients racing to create it all end up
+// with the first one.
+func (m *MetaStore) SetEncryptionSalt(ctx context.Context, salt *EncryptionSalt) (*EncryptionSalt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.encryptionSalt) == 0 && len(salt.Salt) > 0 {
+		if err := m.logEntry(&MetaLogEntry{EncryptionSalt: salt.Salt}); err != nil {
+			return nil, err
+		}
+		m.encryptionSalt = salt.Salt
+	}
+	return &EncryptionSalt{Salt: m.encryptionSalt}, nil
+}
+
 // func (m *MetaStore) GetBlockStoreAddr(ctx context.Context, _ *emptypb.Empty) (*BlockStoreAddr, error) {
 // 	return &BlockStoreAddr{Addr: m.BlockStoreAddr}, nil
 // }
 
 func (m *MetaStore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHashes) (*BlockStoreMap, error) {
+	// the ring is immutable, so grab the current one and do the lookups without holding the lock
+	ring := m.GetConsistentHashRing()
+
 	blockStoreMap := make(map[string]*BlockHashes)
 
+	// with replication every hash is listed under each of its replicas
 	hashes := blockHashesIn.Hashes
 	for _, hash := range hashes {
-		responsibleServer := m.ConsistentHashRing.GetResponsibleServer(hash)
-		if blockStoreMap[responsibleServer] == nil {
-			blockStoreMap[responsibleServer] = &BlockHashes{Hashes: []string{}}
+		for _, responsibleServer := range ring.GetResponsibleServers(hash) {
+			if blockStoreMap[responsibleServer] == nil {
+				blockStoreMap[responsibleServer] = &BlockHashes{Hashes: []string{}}
+			}
+			blockStoreMap[responsibleServer].Hashes = append(blockStoreMap[responsibleServer].Hashes, hash)
 		}
-		blockStoreMap[responsibleServer].Hashes = append(blockStoreMap[responsibleServer].Hashes, hash)
 	}
-	return &BlockStoreMap{BlockStoreMap: blockStoreMap}, nil
+	return &BlockStoreMap{BlockStoreMap: blockStoreMap, RingVersion: ring.GetVersion()}, nil
 }
 
 func (m *MetaStore) GetBlockStoreAddrs(ctx context.Context, _ *emptypb.Empty) (*BlockStoreAddrs, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return &BlockStoreAddrs{BlockStoreAddrs: m.BlockStoreAddrs}, nil
 }
 
+// Adds a blockstore to the ring. The blocks it becomes responsible for are copied to it before
+// the new ring is installed, so clients never get pointed at a server that doesn't have the blocks.
+func (m *MetaStore) AddBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error) {
+	weight := int(change.Weight)
+	if weight <= 0 {
+		weight = 1
+	}
+	return m.changeMembership(func(ring *ConsistentHashRing) (*ConsistentHashRing, error) {
+		return ring.WithServer(change.Addr, weight)
+	})
+}
+
+// Removes a blockstore from the ring, after copying the blocks it was responsible for to their new owners.
+func (m *MetaStore) RemoveBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error) {
+	return m.changeMembership(func(ring *ConsistentHashRing) (*ConsistentHashRing, error) {
+		return ring.WithoutServer(change.Addr)
+	})
+}
+
+func (m *MetaStore) changeMembership(change func(ring *ConsistentHashRing) (*ConsistentHashRing, error)) (*Success, error) {
+	m.membershipMu.Lock()
+	defer m.membershipMu.Unlock()
+
+	oldRing := m.GetConsistentHashRing()
+	newRing, err := change(oldRing)
+	if err != nil {
+		return &Success{Flag: false}, err
+	}
+	if _, err := MigrateBlocks(oldRing, newRing); err != nil {
+		return &Success{Flag: false}, err
+	}
+	if err := m.SetConsistentHashRing(newRing); err != nil {
+		return &Success{Flag: false}, err
+	}
+	// clients kept writing to the old owners while we migrated, pick those blocks up too
+	if _, err := MigrateBlocks(oldRing, newRing); err != nil {
+		return &Success{Flag: false}, err
+	}
+	// the new ring is in place either way, stale copies that stay are only wasted space
+	if _, err := PruneBlocks(oldRing, newRing); err != nil {
+		log.Println("[Rebalancer] could not delete the blocks servers no longer own:", err)
+	}
+	return &Success{Flag: true}, nil
+}
+
+func (m *MetaStore) GetConsistentHashRing() *ConsistentHashRing {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ConsistentHashRing
+}
+
+// SetConsistentHashRing installs a new ring (after a membership change) and logs it.
+func (m *MetaStore) SetConsistentHashRing(ring *ConsistentHashRing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.logEntry(&MetaLogEntry{BlockStoreRing: ring.ToProto()}); err != nil {
+		return err
+	}
+	m.ConsistentHashRing = ring
+	m.BlockStoreAddrs = ring.GetServerAddrs()
+	m.ringChanged = true
+	if err := m.maybeSnapshot(); err != nil {
+		fmt.Println("Could not snapshot metastore: ", err)
+	}
+	return nil
+}
+
 // This line guarantees all method for MetaStore are implemented
 var _ MetaStoreInterface = new(MetaStore)
 
@@ -82,9 +424,48 @@ var _ MetaStoreInterface = new(MetaStore)
 // }
 
 func NewMetaStore(blockStoreAddrs []string) *MetaStore {
+	return NewMetaStoreWithRing(NewConsistentHashRing(blockStoreAddrs))
+}
+
+// NewMetaStoreWithRing creates a metastore that places blocks on the given (e.g. weighted) ring
+func NewMetaStoreWithRing(ring *ConsistentHashRing) *MetaStore {
 	return &MetaStore{
 		FileMetaMap:        map[string]*FileMetaData{},
-		BlockStoreAddrs:    blockStoreAddrs,
-		ConsistentHashRing: NewConsistentHashRing(blockStoreAddrs),
+		BlockStoreAddrs:    ring.GetServerAddrs(),
+		ConsistentHashRing: ring,
+		fileSeqs:           map[string]int64{},
+		epoch:              newEpoch(),
+		updated:            make(chan struct{}),
+	}
+}
+
+// NewDurableMetaStore creates a metastore that keeps its write-ahead log and snapshots in dataDir,
+// recovering the file meta map from them if they already exist. If blockstores were added or removed
+// at runtime, the recovered ring replaces the one given on the command line.
+func NewDurableMetaStore(ring *ConsistentHashRing, dataDir string) (*MetaStore, error) {
+	wal, state, err := OpenMetaWAL(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if state.BlockStoreRing != nil {
+		ring, err = NewConsistentHashRingFromProto(state.BlockStoreRing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m := NewMetaStoreWithRing(ring)
+	m.FileMetaMap = state.FileInfoMap
+	m.seq = state.Seq
+	m.fileSeqs = state.FileSeqs
+	m.encryptionSalt = state.EncryptionSalt
+	m.WAL = wal
+	m.ringChanged = state.BlockStoreRing != nil
+	// a data dir from before epochs existed gets one now, the clients resync once
+	if state.Epoch != "" {
+		m.epoch = state.Epoch
+	} else if err := m.setEpoch(m.epoch); err != nil {
+		wal.Close()
+		return nil, err
 	}
+	return m, nil
 }
@@ -0,0 +1,269 @@
+package surfstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+server side:
+the write-ahead log keeps the metastore durable. Every accepted UpdateFile (and every blockstore membership change)
+is appended to the log (and fsynced) before the in-memory state is changed, and every SNAPSHOT_INTERVAL entries the
+whole state is written to a snapshot so the log can be truncated. On startup we load the snapshot and replay the log on top of it.
+A raft metastore keeps its term, vote and log in the same kind of log. Its snapshot holds the state applied up to
+RaftLogStart and only the log after it, the file map is rebuilt by applying the committed entries after it again.
+
+Each log record is: | length (uint32) | crc32 of payload (uint32) | payload (marshaled MetaLogEntry) |
+*/
+
+const WAL_RECORD_HEADER_SIZE int = 8
+
+type MetaWAL struct {
+	Dir     string
+	logFile *os.File
+	entries int // number of records in the log since the last snapshot
+}
+
+func walLogPath(dir string) string {
+	return filepath.Join(dir, META_WAL_FILENAME)
+}
+
+func walSnapshotPath(dir string) string {
+	return filepath.Join(dir, META_SNAPSHOT_FILENAME)
+}
+
+// OpenMetaWAL opens (or creates) the write-ahead log in dir and returns it together with
+// the state recovered from the snapshot and the log. The recovered BlockStoreRing is nil if membership never changed.
+func OpenMetaWAL(dir string) (*MetaWAL, *MetaSnapshot, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("could not create data dir: %v", err)
+	}
+
+	state, err := loadMetaSnapshot(walSnapshotPath(dir))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logFile, err := os.OpenFile(walLogPath(dir), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open write-ahead log: %v", err)
+	}
+
+	entries, validSize, err := replayMetaLog(logFile, state)
+	if err != nil {
+		logFile.Close()
+		return nil, nil, err
+	}
+
+	// a crash in the middle of an append leaves a torn record at the tail, that update was never acknowledged so drop it
+	if err := logFile.Truncate(validSize); err != nil {
+		logFile.Close()
+		return nil, nil, fmt.Errorf("could not truncate write-ahead log: %v", err)
+	}
+	if _, err := logFile.Seek(validSize, io.SeekStart); err != nil {
+		logFile.Close()
+		return nil, nil, err
+	}
+
+	return &MetaWAL{Dir: dir, logFile: logFile, entries: entries}, state, nil
+}
+
+func loadMetaSnapshot(path string) (*MetaSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &MetaSnapshot{FileInfoMap: make(map[string]*FileMetaData), FileSeqs: make(map[string]int64)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshot: %v", err)
+	}
+
+	snapshot := &MetaSnapshot{}
+	if err := proto.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("corrupted snapshot %s: %v", path, err)
+	}
+	if snapshot.FileInfoMap == nil {
+		snapshot.FileInfoMap = make(map[string]*FileMetaData)
+	}
+	if snapshot.FileSeqs == nil {
+		snapshot.FileSeqs = make(map[string]int64)
+	}
+	// snapshots written before change sequences existed have none, number those files in name order
+	filenames := []string{}
+	for filename := range snapshot.FileInfoMap {
+		if _, ok := snapshot.FileSeqs[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		snapshot.Seq++
+		snapshot.FileSeqs[filename] = snapshot.Seq
+	}
+	return snapshot, nil
+}
+
+// replayMetaLog applies every complete record in the log to state and returns the number of records
+// and the size of the valid prefix of the log.
+func replayMetaLog(logFile *os.File, state *MetaSnapshot) (int, int64, error) {
+	if _, err := logFile.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	reader := bufio.NewReader(logFile)
+
+	entries := 0
+	var validSize int64 = 0
+	header := make([]byte, WAL_RECORD_HEADER_SIZE)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break // EOF or torn header
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		checksum := binary.BigEndian.Uint32(header[4:8])
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			break // torn payload
+		}
+		if crc32.ChecksumIEEE(payload) != checksum {
+			break
+		}
+
+		entry := &MetaLogEntry{}
+		if err := proto.Unmarshal(payload, entry); err != nil {
+			break
+		}
+		applyMetaLogEntry(state, entry)
+
+		entries++
+		validSize += int64(WAL_RECORD_HEADER_SIZE) + int64(length)
+	}
+	return entries, validSize, nil
+}
+
+func applyMetaLogEntry(state *MetaSnapshot, entry *MetaLogEntry) {
+	if entry.FileMetaData != nil {
+		// every accepted update is the next change in the sequence, exactly as when it was first applied
+		state.FileInfoMap[entry.FileMetaData.Filename] = entry.FileMetaData
+		state.Seq++
+		state.FileSeqs[entry.FileMetaData.Filename] = state.Seq
+	}
+	if entry.BlockStoreRing != nil {
+		state.BlockStoreRing = entry.BlockStoreRing
+	}
+	if entry.RaftState != nil {
+		state.RaftState = entry.RaftState
+	}
+	if entry.Epoch != "" {
+		state.Epoch = entry.Epoch
+	}
+	if len(entry.EncryptionSalt) > 0 {
+		state.EncryptionSalt = entry.EncryptionSalt
+	}
+	if entry.RaftLog != nil {
+		// the log in the snapshot starts at RaftLogStart, what comes before it is compacted and never rewritten
+		from := entry.RaftLog.FromIndex - state.RaftLogStart
+		entries := entry.RaftLog.Entries
+		if from < 0 && -from < int64(len(entries)) {
+			entries = entries[-from:]
+			from = 0
+		}
+		if from >= 0 && from <= int64(len(state.RaftLog)) {
+			state.RaftLog = append(state.RaftLog[:from], entries...)
+		}
+	}
+}
+
+// Append writes the entry to the end of the log and fsyncs it, so the update survives a crash once this returns.
+func (w *MetaWAL) Append(entry *MetaLogEntry) error {
+	payload, err := proto.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, WAL_RECORD_HEADER_SIZE+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[WAL_RECORD_HEADER_SIZE:], payload)
+
+	if _, err := w.logFile.Write(record); err != nil {
+		return fmt.Errorf("could not append to write-ahead log: %v", err)
+	}
+	if err := w.logFile.Sync(); err != nil {
+		return fmt.Errorf("could not sync write-ahead log: %v", err)
+	}
+	w.entries++
+	return nil
+}
+
+// NeedsSnapshot reports whether enough entries have been appended since the last snapshot.
+func (w *MetaWAL) NeedsSnapshot() bool {
+	return w.entries >= SNAPSHOT_INTERVAL
+}
+
+// Snapshot writes the whole state to the snapshot file and truncates the log.
+// The snapshot is written to a temp file and renamed so a crash never leaves a half-written snapshot behind.
+func (w *MetaWAL) Snapshot(state *MetaSnapshot) error {
+	data, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	snapshotPath := walSnapshotPath(w.Dir)
+	tmpPath := snapshotPath + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("could not create snapshot: %v", err)
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("could not write snapshot: %v", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("could not sync snapshot: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, snapshotPath); err != nil {
+		return fmt.Errorf("could not install snapshot: %v", err)
+	}
+	if err := syncDir(w.Dir); err != nil {
+		return err
+	}
+
+	// everything in the log is now covered by the snapshot
+	if err := w.logFile.Truncate(0); err != nil {
+		return fmt.Errorf("could not truncate write-ahead log: %v", err)
+	}
+	if _, err := w.logFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := w.logFile.Sync(); err != nil {
+		return err
+	}
+	w.entries = 0
+	return nil
+}
+
+func (w *MetaWAL) Close() error {
+	return w.logFile.Close()
+}
+
+// syncDir fsyncs a directory so that renames inside it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,238 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"sync"
+	"testing"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+const TEST_WRITERS int = 16
+const TEST_UPDATES_PER_WRITER int = 50
+
+// every writer keeps trying to bump the version of the same file, so they constantly race between
+// reading the current version and updating it. Exactly one update per version may be accepted.
+func hammerUpdateFile(t *testing.T, metaStore *MetaStore, filename string) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := make(map[int32]int) // version : how many writers got it accepted
+
+	for w := 0; w < TEST_WRITERS; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < TEST_UPDATES_PER_WRITER; i++ {
+				fileInfoMap, err := metaStore.GetFileInfoMap(ctx, &emptypb.Empty{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				next := int32(1)
+				if current, ok := fileInfoMap.FileInfoMap[filename]; ok {
+					next = current.Version + 1
+				}
+				hash := fmt.Sprintf("writer %d update %d", w, i)
+				version, err := metaStore.UpdateFile(ctx, &FileMetaData{Filename: filename, Version: next, BlockHashList: []string{hash}})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if version.Version != -1 {
+					mu.Lock()
+					accepted[version.Version]++
+					mu.Unlock()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	fileInfoMap, _ := metaStore.GetFileInfoMap(ctx, &emptypb.Empty{})
+	final := fileInfoMap.FileInfoMap[filename].Version
+	if int(final) != len(accepted) {
+		t.Errorf("%s is at version %d but %d versions were accepted", filename, final, len(accepted))
+	}
+	for version, count := range accepted {
+		if count != 1 {
+			t.Errorf("version %d of %s was accepted %d times", version, filename, count)
+		}
+		if version < 1 || version > final {
+			t.Errorf("accepted version %d of %s is out of range 1..%d", version, filename, final)
+		}
+	}
+}
+
+func TestMetaStoreConcurrentUpdateFile(t *testing.T) {
+	metaStore := NewMetaStore([]string{"localhost:8081"})
+
+	var wg sync.WaitGroup
+	for _, filename := range []string{"a.txt", "b.txt", "dir/c.txt"} {
+		wg.Add(1)
+		go func(filename string) {
+			defer wg.Done()
+			hammerUpdateFile(t, metaStore, filename)
+		}(filename)
+	}
+	wg.Wait()
+
+	// every accepted update is one change in the sequence
+	changes, err := metaStore.GetChangesSince(context.Background(), &ChangesRequest{SinceSeq: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.FileMetaData) != 3 {
+		t.Errorf("expected 3 changed files, got %d", len(changes.FileMetaData))
+	}
+	total := int64(0)
+	for _, fileMetaData := range changes.FileMetaData {
+		total += int64(fileMetaData.Version)
+	}
+	if changes.Seq != total {
+		t.Errorf("seq is %d after %d accepted updates", changes.Seq, total)
+	}
+}
+
+func TestDurableMetaStoreConcurrentUpdateFile(t *testing.T) {
+	dir := t.TempDir()
+	ring := NewConsistentHashRing([]string{"localhost:8081"})
+	metaStore, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hammerUpdateFile(t, metaStore, "a.txt")
+	before, _ := metaStore.GetFileInfoMap(context.Background(), &emptypb.Empty{})
+	metaStore.WAL.Close()
+
+	// the log has to hold exactly the accepted updates, in order
+	recovered, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.WAL.Close()
+	after, _ := recovered.GetFileInfoMap(context.Background(), &emptypb.Empty{})
+	got, want := after.FileInfoMap["a.txt"], before.FileInfoMap["a.txt"]
+	if got.Version != want.Version || got.BlockHashList[0] != want.BlockHashList[0] {
+		t.Errorf("recovered version %d (%s), expected %d (%s)", got.Version, got.BlockHashList[0], want.Version, want.BlockHashList[0])
+	}
+}
+
+// a cursor is only good for the metastore history it came from: a durable metastore keeps its epoch across restarts,
+// a metastore that starts over gets a new one and refuses the old cursor even if its seq got past it again
+func TestMetaStoreEpoch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ring := NewConsistentHashRing([]string{"localhost:8081"})
+	metaStore, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metaStore.UpdateFile(ctx, &FileMetaData{Filename: "a.txt", Version: 1, BlockHashList: []string{"h"}})
+	changes, err := metaStore.GetChangesSince(ctx, &ChangesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Epoch == "" {
+		t.Fatal("metastore has no epoch")
+	}
+	metaStore.WAL.Close()
+
+	recovered, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.WAL.Close()
+	if _, err := recovered.GetChangesSince(ctx, &ChangesRequest{SinceSeq: changes.Seq, Epoch: changes.Epoch}); err != nil {
+		t.Errorf("recovered metastore refused its own cursor: %v", err)
+	}
+
+	fresh := NewMetaStoreWithRing(ring)
+	for version := int32(1); version <= 3; version++ {
+		fresh.UpdateFile(ctx, &FileMetaData{Filename: "b.txt", Version: version, BlockHashList: []string{"h"}})
+	}
+	if _, err := fresh.GetChangesSince(ctx, &ChangesRequest{SinceSeq: changes.Seq, Epoch: changes.Epoch}); status.Code(err) != codes.OutOfRange {
+		t.Errorf("a new metastore accepted a cursor of another epoch: %v", err)
+	}
+}
+
+// the first salt set wins, and it survives a restart, otherwise the clients would derive another key
+func TestMetaStoreEncryptionSalt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ring := NewConsistentHashRing([]string{"localhost:8081"})
+	metaStore, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if salt, _ := metaStore.GetEncryptionSalt(ctx, &emptypb.Empty{}); len(salt.Salt) != 0 {
+		t.Fatalf("new metastore has salt %x", salt.Salt)
+	}
+	first, err := metaStore.SetEncryptionSalt(ctx, &EncryptionSalt{Salt: []byte("first")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := metaStore.SetEncryptionSalt(ctx, &EncryptionSalt{Salt: []byte("second")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first.Salt) != "first" || string(second.Salt) != "first" {
+		t.Errorf("got salts %q and %q, expected the first one both times", first.Salt, second.Salt)
+	}
+	// the salt is not a file
+	if fileInfoMap, _ := metaStore.GetFileInfoMap(ctx, &emptypb.Empty{}); len(fileInfoMap.FileInfoMap) != 0 {
+		t.Errorf("the salt shows up in the file map: %v", fileInfoMap.FileInfoMap)
+	}
+	metaStore.WAL.Close()
+
+	recovered, err := NewDurableMetaStore(ring, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovered.WAL.Close()
+	if salt, _ := recovered.GetEncryptionSalt(ctx, &emptypb.Empty{}); string(salt.Salt) != "first" {
+		t.Errorf("recovered salt %q, expected %q", salt.Salt, "first")
+	}
+}
+
+// a client that placed its blocks with the ring from before a membership change has to place them again
+func TestMetaStoreStaleRing(t *testing.T) {
+	ctx := context.Background()
+	metaStore := NewMetaStore([]string{"localhost:8081"})
+	blockStoreMap, err := metaStore.GetBlockStoreMap(ctx, &BlockHashes{Hashes: []string{"h"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldVersion := blockStoreMap.RingVersion
+
+	newRing, err := metaStore.GetConsistentHashRing().WithServer("localhost:8082", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := metaStore.SetConsistentHashRing(newRing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := metaStore.UpdateFile(ctx, &FileMetaData{Filename: "a.txt", Version: 1, BlockHashList: []string{"h"}, RingVersion: oldVersion}); !IsStaleRing(err) {
+		t.Fatalf("update placed with ring %d was not refused after the ring changed: %v", oldVersion, err)
+	}
+
+	blockStoreMap, _ = metaStore.GetBlockStoreMap(ctx, &BlockHashes{Hashes: []string{"h"}})
+	if blockStoreMap.RingVersion == oldVersion {
+		t.Fatalf("ring version stayed %d after a membership change", oldVersion)
+	}
+	version, err := metaStore.UpdateFile(ctx, &FileMetaData{Filename: "a.txt", Version: 1, BlockHashList: []string{"h"}, RingVersion: blockStoreMap.RingVersion})
+	if err != nil || version.Version != 1 {
+		t.Fatalf("update placed with the current ring got %v, %v", version, err)
+	}
+	// clients that don't send a ring version (and deletions, which have no blocks) are not checked
+	if _, err := metaStore.UpdateFile(ctx, &FileMetaData{Filename: "a.txt", Version: 2, BlockHashList: []string{TOMBSTONE_HASHVALUE}}); err != nil {
+		t.Fatal(err)
+	}
+	fileInfoMap, _ := metaStore.GetFileInfoMap(ctx, &emptypb.Empty{})
+	if fileInfoMap.FileInfoMap["a.txt"].RingVersion != 0 {
+		t.Errorf("the ring version was stored with the file")
+	}
+}
@@ -0,0 +1,101 @@
+package surfstore
+
+import (
+	"log"
+	"math/rand"
+	"time"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+/*
+client side:
+how long each kind of call may take, and how calls that failed for a transient reason are retried.
+Only idempotent calls are retried: the reads, and PutBlock/PutBlocks since storing a block twice stores the same block.
+UpdateFile is never retried, if the first attempt went through but the answer got lost, the retry would be
+rejected as an old version and look like a conflict.
+*/
+
+const DEFAULT_META_TIMEOUT time.Duration = time.Second
+const DEFAULT_BLOCK_TIMEOUT time.Duration = time.Second
+const DEFAULT_STREAM_TIMEOUT time.Duration = time.Minute
+const DEFAULT_MAX_RETRIES int = 3
+const DEFAULT_INITIAL_BACKOFF time.Duration = 100 * time.Millisecond
+const DEFAULT_MAX_BACKOFF time.Duration = 5 * time.Second
+
+type RPCOptions struct {
+	MetaTimeout   time.Duration // a single call to a metastore
+	BlockTimeout  time.Duration // a single GetBlock, PutBlock, HasBlocks or GetBlockHashes call
+	StreamTimeout time.Duration // a whole PutBlocks or GetBlocks stream
+
+	MaxRetries     int           // how often an idempotent call is retried after a transient error, 0 never retries
+	InitialBackoff time.Duration // the most we wait before the first retry, doubled for every further one
+	MaxBackoff     time.Duration // the most we ever wait before a retry
+}
+
+func DefaultRPCOptions() RPCOptions {
+	return RPCOptions{
+		MetaTimeout:    DEFAULT_META_TIMEOUT,
+		BlockTimeout:   DEFAULT_BLOCK_TIMEOUT,
+		StreamTimeout:  DEFAULT_STREAM_TIMEOUT,
+		MaxRetries:     DEFAULT_MAX_RETRIES,
+		InitialBackoff: DEFAULT_INITIAL_BACKOFF,
+		MaxBackoff:     DEFAULT_MAX_BACKOFF,
+	}
+}
+
+// unset durations (e.g. in RPCClient{}) fall back to the defaults, a zero timeout would fail every call
+func orDefault(d time.Duration, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+func (o RPCOptions) metaTimeout() time.Duration {
+	return orDefault(o.MetaTimeout, DEFAULT_META_TIMEOUT)
+}
+
+func (o RPCOptions) blockTimeout() time.Duration {
+	return orDefault(o.BlockTimeout, DEFAULT_BLOCK_TIMEOUT)
+}
+
+func (o RPCOptions) streamTimeout() time.Duration {
+	return orDefault(o.StreamTimeout, DEFAULT_STREAM_TIMEOUT)
+}
+
+// IsRetryable reports whether a call that failed with err may succeed if it is simply made again.
+// Everything else (a missing block, a bad argument, an old version, ...) fails the same way every time.
+func IsRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	case codes.FailedPrecondition:
+		// ERR_NOT_LEADER from every metastore, they are in the middle of an election
+		return true
+	}
+	return false
+}
+
+// retry makes the call until it succeeds, fails with an error that isn't retryable, or was retried MaxRetries times.
+// Before each retry it sleeps a random time up to the backoff (full jitter, so clients that failed together don't
+// come back together), and the backoff doubles every time up to MaxBackoff.
+func (o RPCOptions) retry(call func() error) error {
+	backoff := orDefault(o.InitialBackoff, DEFAULT_INITIAL_BACKOFF)
+	maxBackoff := orDefault(o.MaxBackoff, DEFAULT_MAX_BACKOFF)
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= o.MaxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		sleep := time.Duration(rand.Int63n(int64(backoff))) + 1
+		log.Println("[RPCClient] retrying in", sleep, "after:", err)
+		time.Sleep(sleep)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,1015 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+/*
+server side:
+a raft-replicated metastore. Every metastore in the cluster runs a RaftSurfstore, they elect a leader and only the
+leader serves clients. UpdateFile is appended to the leader's log, replicated to the followers with AppendEntries and
+only applied to the underlying MetaStore (and answered) once a majority of the cluster has the entry.
+Followers reject client calls with ERR_NOT_LEADER so the client moves on to the next metastore.
+With a data dir, the term, the vote and the log are written to a write-ahead log before any RPC is answered, so
+a restarted metastore never votes twice in a term or forgets entries it acknowledged. The file map itself isn't
+logged, the committed entries are applied to it again once the leader tells us the commit index.
+Every SNAPSHOT_INTERVAL applied entries the applied state is kept as a snapshot and the log up to lastApplied is
+dropped. A follower that needs entries the leader no longer has gets the snapshot with InstallSnapshot instead.
+
+The log is 0-indexed, -1 means "nothing" for prevLogIndex, commitIndex, lastApplied and snapshotIndex.
+s.log only holds the entries after snapshotIndex, use entry() and termAt() to look them up by index.
+*/
+
+const RAFT_TICK_INTERVAL = 20 * time.Millisecond
+const RAFT_HEARTBEAT_INTERVAL = 100 * time.Millisecond
+const RAFT_ELECTION_TIMEOUT_MIN = 500 * time.Millisecond
+const RAFT_ELECTION_TIMEOUT_MAX = 1000 * time.Millisecond
+const RAFT_RPC_TIMEOUT = 200 * time.Millisecond
+
+var ERR_SERVER_CRASHED = status.Error(codes.Unavailable, "server is crashed")
+var ERR_NOT_LEADER = status.Error(codes.FailedPrecondition, "server is not the leader")
+var ERR_NO_MAJORITY = status.Error(codes.Unavailable, "could not reach a majority of the cluster")
+
+// what applying an entry gave, for the client that proposed it
+type applyResult struct {
+	version *Version
+	err     error
+}
+
+type RaftSurfstore struct {
+	Id        int64
+	PeerAddrs []string // every metastore in the cluster, including this one, indexed by id
+	MetaStore *MetaStore
+	WAL       *MetaWAL // nil if the raft state is only kept in memory
+
+	mu               sync.Mutex
+	term             int64
+	votedFor         int64
+	log              []*UpdateOperation // the entries after snapshotIndex
+	snapshotIndex    int64              // the last entry compacted into snapshot
+	snapshotTerm     int64
+	snapshot         *MetaSnapshot // the metastore state applied up to snapshotIndex, nil before the first compaction
+	snapshotInterval int64
+	commitIndex      int64
+	lastApplied      int64
+	isLeader         bool
+	isCrashed        bool
+	nextIndex        []int64 // leader only
+	matchIndex       []int64 // leader only
+	electionDeadline time.Time
+	pending          map[int64]chan applyResult // leader only: clients waiting for the entry at that index to be applied
+	peerConns        []*grpc.ClientConn
+
+	membershipMu sync.Mutex // only one blockstore is added or removed at a time
+
+	UnimplementedMetaStoreServer
+	UnimplementedRaftSurfstoreServer
+}
+
+/* Client facing MetaStore interface, only served by the leader */
+
+func (s *RaftSurfstore) GetFileInfoMap(ctx context.Context, empty *emptypb.Empty) (*FileInfoMap, error) {
+	if err := s.confirmLeadership(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MetaStore.GetFileInfoMap(ctx, empty)
+}
+
+func (s *RaftSurfstore) GetChangesSince(ctx context.Context, changesRequest *ChangesRequest) (*FileChanges, error) {
+	if err := s.confirmLeadership(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MetaStore.GetChangesSince(ctx, changesRequest)
+}
+
+func (s *RaftSurfstore) GetEncryptionSalt(ctx context.Context, empty *emptypb.Empty) (*EncryptionSalt, error) {
+	if err := s.confirmLeadership(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MetaStore.GetEncryptionSalt(ctx, empty)
+}
+
+// the salt goes through the log like a file update, every metastore keeps the first one committed
+func (s *RaftSurfstore) SetEncryptionSalt(ctx context.Context, salt *EncryptionSalt) (*EncryptionSalt, error) {
+	current, err := s.GetEncryptionSalt(ctx, &emptypb.Empty{})
+	if err != nil || len(current.Salt) > 0 {
+		return current, err
+	}
+	if _, err := s.propose(ctx, &UpdateOperation{EncryptionSalt: salt.Salt}); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.MetaStore.GetEncryptionSalt(ctx, &emptypb.Empty{})
+}
+
+func (s *RaftSurfstore) UpdateFile(ctx context.Context, fileMetaData *FileMetaData) (*Version, error) {
+	return s.propose(ctx, &UpdateOperation{FileMetaData: fileMetaData})
+}
+
+// propose appends the operation to the leader's log and waits until it is committed and applied
+func (s *RaftSurfstore) propose(ctx context.Context, op *UpdateOperation) (*Version, error) {
+	s.mu.Lock()
+	if s.isCrashed {
+		s.mu.Unlock()
+		return nil, ERR_SERVER_CRASHED
+	}
+	if !s.isLeader {
+		s.mu.Unlock()
+		return nil, ERR_NOT_LEADER
+	}
+	op.Term = s.term
+	s.log = append(s.log, op)
+	index := s.lastIndex()
+	if err := s.persistLog(index); err != nil {
+		s.log = s.log[:len(s.log)-1]
+		s.mu.Unlock()
+		return nil, err
+	}
+	result := make(chan applyResult, 1)
+	s.pending[index] = result
+	s.mu.Unlock()
+
+	// replicate right away instead of waiting for the next heartbeat
+	go s.broadcastAppendEntries()
+
+	select {
+	case applied, ok := <-result:
+		if !ok { // lost leadership before the entry was applied
+			return nil, ERR_NOT_LEADER
+		}
+		return applied.version, applied.err
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (s *RaftSurfstore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHashes) (*BlockStoreMap, error) {
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	return s.MetaStore.GetBlockStoreMap(ctx, blockHashesIn)
+}
+
+func (s *RaftSurfstore) GetBlockStoreAddrs(ctx context.Context, empty *emptypb.Empty) (*BlockStoreAddrs, error) {
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	return s.MetaStore.GetBlockStoreAddrs(ctx, empty)
+}
+
+// WatchFiles is served by the leader, and only while it stays the leader: a leader that steps down or crashes stops
+// applying new entries, so the stream ends with ERR_NOT_LEADER and the client resumes from its last seq on the new leader.
+// Every metastore applies the same updates in the same order, so the seqs are the same on all of them.
+func (s *RaftSurfstore) WatchFiles(watchRequest *WatchRequest, stream MetaStore_WatchFilesServer) error {
+	if err := s.checkLeader(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	var leaderErr error
+	go func() {
+		ticker := time.NewTicker(RAFT_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.checkLeader(); err != nil {
+					leaderErr = err
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err := s.MetaStore.WatchFiles(watchRequest, &watchFilesStream{MetaStore_WatchFilesServer: stream, ctx: ctx})
+	if ctx.Err() != nil && stream.Context().Err() == nil {
+		return leaderErr // cancel() happened before the watch returned, so leaderErr is set
+	}
+	return err
+}
+
+// watchFilesStream replaces the context of a WatchFiles stream, so the stream can be ended from the server side
+type watchFilesStream struct {
+	MetaStore_WatchFilesServer
+	ctx context.Context
+}
+
+func (w *watchFilesStream) Context() context.Context {
+	return w.ctx
+}
+
+func (s *RaftSurfstore) AddBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error) {
+	weight := int(change.Weight)
+	if weight <= 0 {
+		weight = 1
+	}
+	return s.changeMembership(ctx, func(ring *ConsistentHashRing) (*ConsistentHashRing, error) {
+		return ring.WithServer(change.Addr, weight)
+	})
+}
+
+func (s *RaftSurfstore) RemoveBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error) {
+	return s.changeMembership(ctx, func(ring *ConsistentHashRing) (*ConsistentHashRing, error) {
+		return ring.WithoutServer(change.Addr)
+	})
+}
+
+// the leader migrates the blocks, then the new ring goes through the log so every metastore installs it
+func (s *RaftSurfstore) changeMembership(ctx context.Context, change func(ring *ConsistentHashRing) (*ConsistentHashRing, error)) (*Success, error) {
+	if err := s.checkLeader(); err != nil {
+		return &Success{Flag: false}, err
+	}
+	s.membershipMu.Lock()
+	defer s.membershipMu.Unlock()
+
+	oldRing := s.MetaStore.GetConsistentHashRing()
+	newRing, err := change(oldRing)
+	if err != nil {
+		return &Success{Flag: false}, err
+	}
+	if _, err := MigrateBlocks(oldRing, newRing); err != nil {
+		return &Success{Flag: false}, err
+	}
+	if _, err := s.propose(ctx, &UpdateOperation{BlockStoreRing: newRing.ToProto()}); err != nil {
+		return &Success{Flag: false}, err
+	}
+	if _, err := MigrateBlocks(oldRing, newRing); err != nil {
+		return &Success{Flag: false}, err
+	}
+	// the new ring is in place either way, stale copies that stay are only wasted space
+	if _, err := PruneBlocks(oldRing, newRing); err != nil {
+		log.Println("[Rebalancer] could not delete the blocks servers no longer own:", err)
+	}
+	return &Success{Flag: true}, nil
+}
+
+func (s *RaftSurfstore) checkLeader() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCrashed {
+		return ERR_SERVER_CRASHED
+	}
+	if !s.isLeader {
+		return ERR_NOT_LEADER
+	}
+	return nil
+}
+
+// confirmLeadership makes sure a majority still follows us before serving a read,
+// so a deposed leader never returns a stale file map
+func (s *RaftSurfstore) confirmLeadership() error {
+	if err := s.checkLeader(); err != nil {
+		return err
+	}
+	if !s.broadcastAppendEntries() {
+		if err := s.checkLeader(); err != nil {
+			return err
+		}
+		return ERR_NO_MAJORITY
+	}
+	return nil
+}
+
+/* Raft interface between the metastores */
+
+func (s *RaftSurfstore) AppendEntries(ctx context.Context, input *AppendEntryInput) (*AppendEntryOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCrashed {
+		return nil, ERR_SERVER_CRASHED
+	}
+
+	output := &AppendEntryOutput{ServerId: s.Id, Term: s.term, Success: false, MatchedIndex: -1}
+	if input.Term < s.term { // stale leader
+		return output, nil
+	}
+	if err := s.becomeFollower(input.Term); err != nil {
+		return nil, err
+	}
+	s.resetElectionTimer()
+	output.Term = s.term
+
+	// our log must contain the entry right before the new ones, otherwise tell the leader how far back to go
+	// (the entries we compacted are committed, they match the leader's)
+	if input.PrevLogIndex > s.lastIndex() {
+		output.MatchedIndex = s.lastIndex()
+		return output, nil
+	}
+	if input.PrevLogIndex >= s.snapshotIndex && s.termAt(input.PrevLogIndex) != input.PrevLogTerm {
+		output.MatchedIndex = input.PrevLogIndex - 1
+		return output, nil
+	}
+
+	firstChanged := int64(-1)
+	for i, entry := range input.Entries {
+		index := input.PrevLogIndex + 1 + int64(i)
+		if index <= s.snapshotIndex {
+			continue
+		}
+		if index <= s.lastIndex() {
+			if s.entry(index).Term == entry.Term {
+				continue
+			}
+			s.log = s.log[:index-s.snapshotIndex-1] // conflicting entry, drop it and everything after it
+		}
+		if firstChanged < 0 {
+			firstChanged = index
+		}
+		s.log = append(s.log, entry)
+	}
+	// the leader counts the entries as ours once we answer, they have to be on disk first
+	if firstChanged >= 0 {
+		if err := s.persistLog(firstChanged); err != nil {
+			s.log = s.log[:firstChanged-s.snapshotIndex-1]
+			return nil, err
+		}
+	}
+
+	lastNewIndex := input.PrevLogIndex + int64(len(input.Entries))
+	commitIndex := input.LeaderCommit
+	if lastNewIndex < commitIndex {
+		commitIndex = lastNewIndex
+	}
+	if commitIndex > s.commitIndex {
+		s.commitIndex = commitIndex
+		s.applyCommitted()
+	}
+
+	output.Success = true
+	output.MatchedIndex = lastNewIndex
+	return output, nil
+}
+
+// InstallSnapshot replaces our state with the leader's snapshot, the part of our log that follows it is kept if it agrees
+func (s *RaftSurfstore) InstallSnapshot(ctx context.Context, input *InstallSnapshotInput) (*AppendEntryOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCrashed {
+		return nil, ERR_SERVER_CRASHED
+	}
+
+	output := &AppendEntryOutput{ServerId: s.Id, Term: s.term, Success: false, MatchedIndex: -1}
+	if input.Term < s.term { // stale leader
+		return output, nil
+	}
+	if err := s.becomeFollower(input.Term); err != nil {
+		return nil, err
+	}
+	s.resetElectionTimer()
+	output.Term = s.term
+
+	snapshot := input.Snapshot
+	snapshotIndex := snapshot.RaftLogStart - 1
+	output.Success = true
+	output.MatchedIndex = snapshotIndex
+	if snapshotIndex <= s.lastApplied { // we already applied all of it
+		return output, nil
+	}
+
+	if err := s.MetaStore.restoreSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	if snapshotIndex <= s.lastIndex() && s.termAt(snapshotIndex) == snapshot.RaftSnapshotTerm {
+		s.log = append([]*UpdateOperation{}, s.log[snapshotIndex-s.snapshotIndex:]...)
+	} else {
+		s.log = []*UpdateOperation{}
+	}
+	s.snapshot = snapshot
+	s.snapshotIndex = snapshotIndex
+	s.snapshotTerm = snapshot.RaftSnapshotTerm
+	if s.commitIndex < snapshotIndex {
+		s.commitIndex = snapshotIndex
+	}
+	s.lastApplied = snapshotIndex
+	// the log on disk may no longer agree with ours, the snapshot replaces both
+	if err := s.writeSnapshot(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (s *RaftSurfstore) RequestVote(ctx context.Context, input *RequestVoteInput) (*RequestVoteOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCrashed {
+		return nil, ERR_SERVER_CRASHED
+	}
+
+	if input.Term < s.term {
+		return &RequestVoteOutput{Term: s.term, VoteGranted: false}, nil
+	}
+	if err := s.becomeFollower(input.Term); err != nil {
+		return nil, err
+	}
+
+	lastIndex, lastTerm := s.lastLogIndexAndTerm()
+	upToDate := input.LastLogTerm > lastTerm || (input.LastLogTerm == lastTerm && input.LastLogIndex >= lastIndex)
+	if (s.votedFor == -1 || s.votedFor == input.CandidateId) && upToDate {
+		s.votedFor = input.CandidateId
+		if err := s.persistState(); err != nil {
+			s.votedFor = -1
+			return nil, err
+		}
+		s.resetElectionTimer()
+		return &RequestVoteOutput{Term: s.term, VoteGranted: true}, nil
+	}
+	return &RequestVoteOutput{Term: s.term, VoteGranted: false}, nil
+}
+
+func (s *RaftSurfstore) SetLeader(ctx context.Context, _ *emptypb.Empty) (*Success, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isCrashed {
+		return &Success{Flag: false}, ERR_SERVER_CRASHED
+	}
+	s.term++
+	s.votedFor = s.Id
+	if err := s.persistState(); err != nil {
+		return &Success{Flag: false}, err
+	}
+	s.becomeLeader()
+	return &Success{Flag: true}, nil
+}
+
+func (s *RaftSurfstore) SendHeartbeat(ctx context.Context, _ *emptypb.Empty) (*Success, error) {
+	if err := s.checkLeader(); err != nil {
+		return &Success{Flag: false}, err
+	}
+	return &Success{Flag: s.broadcastAppendEntries()}, nil
+}
+
+func (s *RaftSurfstore) Crash(ctx context.Context, _ *emptypb.Empty) (*Success, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isCrashed = true
+	// a crashed leader steps down, otherwise it would come back believing it still leads its term
+	s.becomeFollower(s.term) // the term doesn't change, so nothing is persisted and it can't fail
+	s.failPending()
+	return &Success{Flag: true}, nil
+}
+
+func (s *RaftSurfstore) Restore(ctx context.Context, _ *emptypb.Empty) (*Success, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isCrashed = false
+	s.resetElectionTimer()
+	return &Success{Flag: true}, nil
+}
+
+func (s *RaftSurfstore) IsCrashed(ctx context.Context, _ *emptypb.Empty) (*CrashedState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &CrashedState{IsCrashed: s.isCrashed}, nil
+}
+
+func (s *RaftSurfstore) GetInternalState(ctx context.Context, empty *emptypb.Empty) (*RaftInternalState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fileInfoMap, _ := s.MetaStore.GetFileInfoMap(ctx, empty)
+	return &RaftInternalState{
+		IsLeader:    s.isLeader,
+		Term:        s.term,
+		CommitIndex: s.commitIndex,
+		Log:         append([]*UpdateOperation{}, s.log...),
+		MetaMap:     fileInfoMap,
+	}, nil
+}
+
+/* Leader election and replication */
+
+// Run drives the election timer and the leader's heartbeats, it never returns.
+func (s *RaftSurfstore) Run() {
+	ticker := time.NewTicker(RAFT_TICK_INTERVAL)
+	defer ticker.Stop()
+
+	lastHeartbeat := time.Time{}
+	for range ticker.C {
+		s.mu.Lock()
+		crashed, leader := s.isCrashed, s.isLeader
+		electionDue := !leader && time.Now().After(s.electionDeadline)
+		s.mu.Unlock()
+
+		if crashed {
+			continue
+		}
+		if leader {
+			if time.Since(lastHeartbeat) >= RAFT_HEARTBEAT_INTERVAL {
+				lastHeartbeat = time.Now()
+				go s.broadcastAppendEntries()
+			}
+		} else if electionDue {
+			s.startElection()
+		}
+	}
+}
+
+func (s *RaftSurfstore) startElection() {
+	s.mu.Lock()
+	s.term++
+	s.votedFor = s.Id
+	s.resetElectionTimer()
+	term := s.term
+	lastIndex, lastTerm := s.lastLogIndexAndTerm()
+	input := &RequestVoteInput{Term: term, CandidateId: s.Id, LastLogIndex: lastIndex, LastLogTerm: lastTerm}
+	// our own vote has to be on disk before we ask for others, or a restart could let us vote again in this term
+	if err := s.persistState(); err != nil {
+		s.mu.Unlock()
+		log.Println("[Raft] server", s.Id, "could not start election:", err)
+		return
+	}
+	votes := 1
+	if s.hasMajority(votes) { // single node cluster
+		s.becomeLeader()
+	}
+	s.mu.Unlock()
+	log.Println("[Raft] server", s.Id, "starts election for term", term)
+
+	for peer := range s.PeerAddrs {
+		if int64(peer) == s.Id {
+			continue
+		}
+		go func(peer int) {
+			client, err := s.peerClient(peer)
+			if err != nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), RAFT_RPC_TIMEOUT)
+			defer cancel()
+			output, err := client.RequestVote(ctx, input)
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if output.Term > s.term {
+				if err := s.becomeFollower(output.Term); err != nil {
+					log.Println("[Raft] server", s.Id, ":", err)
+				}
+				return
+			}
+			if !output.VoteGranted || s.term != term || s.isLeader || s.isCrashed {
+				return
+			}
+			votes++
+			if s.hasMajority(votes) {
+				s.becomeLeader()
+			}
+		}(peer)
+	}
+}
+
+// broadcastAppendEntries sends one round of AppendEntries to every follower and reports
+// whether a majority of the cluster (including us) answered while we were still the leader.
+func (s *RaftSurfstore) broadcastAppendEntries() bool {
+	results := make(chan bool, len(s.PeerAddrs))
+	for peer := range s.PeerAddrs {
+		if int64(peer) == s.Id {
+			continue
+		}
+		go func(peer int) {
+			results <- s.replicateTo(peer)
+		}(peer)
+	}
+
+	reached := 1
+	for i := 0; i < len(s.PeerAddrs)-1; i++ {
+		if <-results {
+			reached++
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isLeader && !s.isCrashed {
+		// without followers nothing in replicateTo commits our entries
+		s.advanceCommitIndex()
+	}
+	return s.isLeader && !s.isCrashed && s.hasMajority(reached)
+}
+
+// replicateTo brings one follower's log up to date with ours, walking nextIndex back until the logs match.
+// Returns whether the follower answered us as the leader of the current term.
+func (s *RaftSurfstore) replicateTo(peer int) bool {
+	client, err := s.peerClient(peer)
+	if err != nil {
+		return false
+	}
+
+	for {
+		s.mu.Lock()
+		if !s.isLeader || s.isCrashed {
+			s.mu.Unlock()
+			return false
+		}
+		term := s.term
+		prevLogIndex := s.nextIndex[peer] - 1
+		if prevLogIndex < s.snapshotIndex {
+			// the entries the follower needs are compacted, it gets the snapshot and then the entries after it
+			if !s.sendSnapshot(client, peer) {
+				return false
+			}
+			continue
+		}
+		input := &AppendEntryInput{
+			Term:         term,
+			LeaderId:     s.Id,
+			PrevLogIndex: prevLogIndex,
+			PrevLogTerm:  s.termAt(prevLogIndex),
+			Entries:      append([]*UpdateOperation{}, s.log[prevLogIndex-s.snapshotIndex:]...),
+			LeaderCommit: s.commitIndex,
+		}
+		s.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), RAFT_RPC_TIMEOUT)
+		output, err := client.AppendEntries(ctx, input)
+		cancel()
+		if err != nil {
+			return false
+		}
+
+		s.mu.Lock()
+		if output.Term > s.term {
+			if err := s.becomeFollower(output.Term); err != nil {
+				log.Println("[Raft] server", s.Id, ":", err)
+			}
+			s.mu.Unlock()
+			return false
+		}
+		if !s.isLeader || s.term != term {
+			s.mu.Unlock()
+			return false
+		}
+		if output.Success {
+			if output.MatchedIndex > s.matchIndex[peer] {
+				s.matchIndex[peer] = output.MatchedIndex
+			}
+			s.nextIndex[peer] = s.matchIndex[peer] + 1
+			s.advanceCommitIndex()
+			s.mu.Unlock()
+			return true
+		}
+
+		// the follower is missing entries or has conflicting ones, back up and try again
+		next := prevLogIndex
+		if output.MatchedIndex+1 < next {
+			next = output.MatchedIndex + 1
+		}
+		if next < 0 {
+			next = 0
+		}
+		s.nextIndex[peer] = next
+		s.mu.Unlock()
+	}
+}
+
+// sendSnapshot sends our snapshot to a follower, must hold s.mu and returns with it released.
+// Returns whether the follower installed it, with s.nextIndex moved past it.
+func (s *RaftSurfstore) sendSnapshot(client RaftSurfstoreClient, peer int) bool {
+	term := s.term
+	input := &InstallSnapshotInput{Term: term, LeaderId: s.Id, Snapshot: s.snapshot}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), RAFT_RPC_TIMEOUT)
+	output, err := client.InstallSnapshot(ctx, input)
+	cancel()
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if output.Term > s.term {
+		if err := s.becomeFollower(output.Term); err != nil {
+			log.Println("[Raft] server", s.Id, ":", err)
+		}
+		return false
+	}
+	if !s.isLeader || s.term != term || !output.Success {
+		return false
+	}
+	if output.MatchedIndex > s.matchIndex[peer] {
+		s.matchIndex[peer] = output.MatchedIndex
+	}
+	s.nextIndex[peer] = s.matchIndex[peer] + 1
+	return true
+}
+
+// advanceCommitIndex commits the newest entry of the current term that a majority has, must hold s.mu
+func (s *RaftSurfstore) advanceCommitIndex() {
+	for n := s.lastIndex(); n > s.commitIndex; n-- {
+		if s.entry(n).Term != s.term { // only entries from our own term are committed by counting
+			break
+		}
+		count := 1
+		for peer := range s.PeerAddrs {
+			if int64(peer) != s.Id && s.matchIndex[peer] >= n {
+				count++
+			}
+		}
+		if s.hasMajority(count) {
+			s.commitIndex = n
+			break
+		}
+	}
+	s.applyCommitted()
+}
+
+// applyCommitted applies every committed entry to the metastore in log order, must hold s.mu
+func (s *RaftSurfstore) applyCommitted() {
+	for s.lastApplied < s.commitIndex {
+		s.lastApplied++
+		entry := s.entry(s.lastApplied)
+
+		applied := applyResult{version: &Version{Version: -1}}
+		if entry.FileMetaData != nil { // an entry with neither is the no-op a new leader appends
+			// every metastore refuses the same updates (e.g. ERR_STALE_RING), they apply the same entries in the same order
+			v, err := s.MetaStore.UpdateFile(context.Background(), entry.FileMetaData)
+			if err != nil {
+				if !IsStaleRing(err) {
+					log.Println("[Raft] could not apply entry", s.lastApplied, ":", err)
+				}
+				applied = applyResult{err: err}
+			} else {
+				applied.version = v
+			}
+		}
+		if entry.Epoch != "" {
+			if err := s.MetaStore.setEpoch(entry.Epoch); err != nil {
+				log.Println("[Raft] could not apply entry", s.lastApplied, ":", err)
+			}
+		}
+		if len(entry.EncryptionSalt) > 0 {
+			if _, err := s.MetaStore.SetEncryptionSalt(context.Background(), &EncryptionSalt{Salt: entry.EncryptionSalt}); err != nil {
+				log.Println("[Raft] could not apply entry", s.lastApplied, ":", err)
+			}
+		}
+		if entry.BlockStoreRing != nil {
+			ring, err := NewConsistentHashRingFromProto(entry.BlockStoreRing)
+			if err == nil {
+				err = s.MetaStore.SetConsistentHashRing(ring)
+			}
+			if err != nil {
+				log.Println("[Raft] could not apply entry", s.lastApplied, ":", err)
+			}
+		}
+
+		if result, ok := s.pending[s.lastApplied]; ok {
+			result <- applied
+			delete(s.pending, s.lastApplied)
+		}
+	}
+	if s.lastApplied-s.snapshotIndex >= s.snapshotInterval {
+		s.compact()
+	}
+}
+
+// compact keeps the applied state as our snapshot and drops the log up to lastApplied, must hold s.mu
+func (s *RaftSurfstore) compact() {
+	snapshot := s.MetaStore.snapshotState()
+	snapshot.RaftLogStart = s.lastApplied + 1
+	snapshot.RaftSnapshotTerm = s.entry(s.lastApplied).Term
+	s.log = append([]*UpdateOperation{}, s.log[s.lastApplied-s.snapshotIndex:]...)
+	s.snapshot = snapshot
+	s.snapshotIndex = s.lastApplied
+	s.snapshotTerm = snapshot.RaftSnapshotTerm
+	// a failed snapshot is not fatal, the log on disk still has everything
+	if err := s.writeSnapshot(); err != nil {
+		log.Println("[Raft] server", s.Id, "could not snapshot:", err)
+	}
+}
+
+// must hold s.mu
+func (s *RaftSurfstore) becomeLeader() {
+	log.Println("[Raft] server", s.Id, "becomes leader for term", s.term)
+	s.isLeader = true
+	s.nextIndex = make([]int64, len(s.PeerAddrs))
+	s.matchIndex = make([]int64, len(s.PeerAddrs))
+	for peer := range s.PeerAddrs {
+		s.nextIndex[peer] = s.lastIndex() + 1
+		s.matchIndex[peer] = -1
+	}
+	// a no-op of our own term lets us commit whatever the previous leader left uncommitted.
+	// The first entry of a log starts a new history of seqs, it carries the epoch all metastores take
+	noop := &UpdateOperation{Term: s.term}
+	if s.lastIndex() == -1 {
+		noop.Epoch = newEpoch()
+	}
+	s.log = append(s.log, noop)
+	if err := s.persistLog(s.lastIndex()); err != nil {
+		// still the leader, the next proposal commits the old entries instead
+		log.Println("[Raft] server", s.Id, "could not log the no-op:", err)
+		s.log = s.log[:len(s.log)-1]
+	}
+	go s.broadcastAppendEntries()
+}
+
+// must hold s.mu. A newer term is persisted before it is returned, the error only says the persisting failed
+func (s *RaftSurfstore) becomeFollower(term int64) error {
+	var err error
+	if term > s.term {
+		s.term = term
+		s.votedFor = -1
+		err = s.persistState()
+	}
+	if s.isLeader {
+		log.Println("[Raft] server", s.Id, "steps down in term", s.term)
+		s.isLeader = false
+		s.failPending()
+	}
+	return err
+}
+
+// persistState logs the current term and vote, must hold s.mu
+func (s *RaftSurfstore) persistState() error {
+	return s.persist(&MetaLogEntry{RaftState: &RaftHardState{Term: s.term, VotedFor: s.votedFor}})
+}
+
+// persistLog logs that the log from fromIndex on is what s.log holds now, must hold s.mu
+func (s *RaftSurfstore) persistLog(fromIndex int64) error {
+	return s.persist(&MetaLogEntry{RaftLog: &RaftLogAppend{FromIndex: fromIndex, Entries: s.log[fromIndex-s.snapshotIndex-1:]}})
+}
+
+// must hold s.mu
+func (s *RaftSurfstore) persist(entry *MetaLogEntry) error {
+	if s.WAL == nil {
+		return nil
+	}
+	if err := s.WAL.Append(entry); err != nil {
+		return err
+	}
+	// a failed snapshot is not fatal, the log still has everything
+	if s.WAL.NeedsSnapshot() {
+		if err := s.writeSnapshot(); err != nil {
+			log.Println("[Raft] server", s.Id, "could not snapshot:", err)
+		}
+	}
+	return nil
+}
+
+// writeSnapshot writes our snapshot, the term, the vote and the log after the snapshot to the WAL, must hold s.mu
+func (s *RaftSurfstore) writeSnapshot() error {
+	if s.WAL == nil {
+		return nil
+	}
+	state := &MetaSnapshot{
+		RaftState:        &RaftHardState{Term: s.term, VotedFor: s.votedFor},
+		RaftLog:          s.log,
+		RaftLogStart:     s.snapshotIndex + 1,
+		RaftSnapshotTerm: s.snapshotTerm,
+	}
+	if s.snapshot != nil {
+		state.FileInfoMap = s.snapshot.FileInfoMap
+		state.BlockStoreRing = s.snapshot.BlockStoreRing
+		state.Seq = s.snapshot.Seq
+		state.FileSeqs = s.snapshot.FileSeqs
+		state.Epoch = s.snapshot.Epoch
+		state.EncryptionSalt = s.snapshot.EncryptionSalt
+	}
+	return s.WAL.Snapshot(state)
+}
+
+// the clients waiting on uncommitted entries get ERR_NOT_LEADER and retry elsewhere, must hold s.mu
+func (s *RaftSurfstore) failPending() {
+	for index, result := range s.pending {
+		close(result)
+		delete(s.pending, index)
+	}
+}
+
+// must hold s.mu
+func (s *RaftSurfstore) resetElectionTimer() {
+	timeout := RAFT_ELECTION_TIMEOUT_MIN + time.Duration(rand.Int63n(int64(RAFT_ELECTION_TIMEOUT_MAX-RAFT_ELECTION_TIMEOUT_MIN)))
+	s.electionDeadline = time.Now().Add(timeout)
+}
+
+// must hold s.mu
+func (s *RaftSurfstore) lastLogIndexAndTerm() (int64, int64) {
+	return s.lastIndex(), s.termAt(s.lastIndex())
+}
+
+// the index of our last entry, compacted or not, must hold s.mu
+func (s *RaftSurfstore) lastIndex() int64 {
+	return s.snapshotIndex + int64(len(s.log))
+}
+
+// the entry at index, which must be after snapshotIndex, must hold s.mu
+func (s *RaftSurfstore) entry(index int64) *UpdateOperation {
+	return s.log[index-s.snapshotIndex-1]
+}
+
+// the term of the entry at index, which must be snapshotIndex or after it (-1 for an empty log), must hold s.mu
+func (s *RaftSurfstore) termAt(index int64) int64 {
+	if index == s.snapshotIndex {
+		return s.snapshotTerm
+	}
+	return s.entry(index).Term
+}
+
+func (s *RaftSurfstore) hasMajority(count int) bool {
+	return count*2 > len(s.PeerAddrs)
+}
+
+// connections to the other metastores are kept open, grpc reconnects them on its own
+func (s *RaftSurfstore) peerClient(peer int) (RaftSurfstoreClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.peerConns[peer] == nil {
+		conn, err := grpc.Dial(s.PeerAddrs[peer], grpc.WithInsecure())
+		if err != nil {
+			return nil, err
+		}
+		s.peerConns[peer] = conn
+	}
+	return NewRaftSurfstoreClient(s.peerConns[peer]), nil
+}
+
+// This line guarantees all method for RaftSurfstore are implemented
+var _ MetaStoreInterface = new(RaftSurfstore)
+var _ RaftInterface = new(RaftSurfstore)
+
+func NewRaftSurfstore(id int64, peerAddrs []string, ring *ConsistentHashRing) (*RaftSurfstore, error) {
+	if id < 0 || id >= int64(len(peerAddrs)) {
+		return nil, fmt.Errorf("server id %d is not in the cluster of %d metastores", id, len(peerAddrs))
+	}
+
+	s := &RaftSurfstore{
+		Id:               id,
+		PeerAddrs:        peerAddrs,
+		MetaStore:        NewMetaStoreWithRing(ring),
+		votedFor:         -1,
+		log:              []*UpdateOperation{},
+		snapshotIndex:    -1,
+		snapshotTerm:     -1,
+		snapshotInterval: int64(SNAPSHOT_INTERVAL),
+		commitIndex:      -1,
+		lastApplied:      -1,
+		pending:          map[int64]chan applyResult{},
+		peerConns:        make([]*grpc.ClientConn, len(peerAddrs)),
+	}
+	s.resetElectionTimer()
+	return s, nil
+}
+
+// NewDurableRaftSurfstore is NewRaftSurfstore with the term, vote and log kept in dataDir, recovering them if they are there
+func NewDurableRaftSurfstore(id int64, peerAddrs []string, ring *ConsistentHashRing, dataDir string) (*RaftSurfstore, error) {
+	s, err := NewRaftSurfstore(id, peerAddrs, ring)
+	if err != nil {
+		return nil, err
+	}
+	wal, state, err := OpenMetaWAL(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if state.RaftState != nil {
+		s.term = state.RaftState.Term
+		s.votedFor = state.RaftState.VotedFor
+	}
+	if state.RaftLogStart > 0 {
+		// everything in the snapshot was applied, the entries after it are applied again once the leader says they are committed
+		snapshot := &MetaSnapshot{
+			FileInfoMap:      state.FileInfoMap,
+			BlockStoreRing:   state.BlockStoreRing,
+			Seq:              state.Seq,
+			FileSeqs:         state.FileSeqs,
+			Epoch:            state.Epoch,
+			EncryptionSalt:   state.EncryptionSalt,
+			RaftLogStart:     state.RaftLogStart,
+			RaftSnapshotTerm: state.RaftSnapshotTerm,
+		}
+		if err := s.MetaStore.restoreSnapshot(snapshot); err != nil {
+			return nil, err
+		}
+		s.snapshot = snapshot
+		s.snapshotIndex = state.RaftLogStart - 1
+		s.snapshotTerm = state.RaftSnapshotTerm
+		s.commitIndex = s.snapshotIndex
+		s.lastApplied = s.snapshotIndex
+	}
+	if state.RaftLog != nil {
+		s.log = state.RaftLog
+	}
+	s.WAL = wal
+	return s, nil
+}
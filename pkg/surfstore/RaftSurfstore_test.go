@@ -0,0 +1,248 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+const TEST_RAFT_SERVERS int = 3
+const TEST_RAFT_TIMEOUT = 10 * time.Second
+
+type testRaftCluster struct {
+	servers     []*RaftSurfstore
+	raftClients []RaftSurfstoreClient
+	metaClients []MetaStoreClient
+}
+
+// startTestRaftCluster runs an in-memory raft cluster until the test ends, the metastores compact their log
+// every snapshotInterval applied entries
+func startTestRaftCluster(t *testing.T, snapshotInterval int64) *testRaftCluster {
+	listeners := make([]net.Listener, TEST_RAFT_SERVERS)
+	peerAddrs := make([]string, TEST_RAFT_SERVERS)
+	for i := range listeners {
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners[i] = listener
+		peerAddrs[i] = listener.Addr().String()
+	}
+
+	cluster := &testRaftCluster{}
+	ring := NewConsistentHashRing([]string{"localhost:0"})
+	for i, listener := range listeners {
+		server, err := NewRaftSurfstore(int64(i), peerAddrs, ring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.snapshotInterval = snapshotInterval
+		grpcServer := grpc.NewServer()
+		RegisterMetaStoreServer(grpcServer, server)
+		RegisterRaftSurfstoreServer(grpcServer, server)
+		go grpcServer.Serve(listener)
+		t.Cleanup(grpcServer.Stop)
+
+		conn, err := grpc.Dial(peerAddrs[i], grpc.WithInsecure())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		cluster.servers = append(cluster.servers, server)
+		cluster.raftClients = append(cluster.raftClients, NewRaftSurfstoreClient(conn))
+		cluster.metaClients = append(cluster.metaClients, NewMetaStoreClient(conn))
+	}
+	for _, server := range cluster.servers {
+		go server.Run()
+	}
+	// Run never returns, a crashed metastore at least stops holding elections once the test is over
+	t.Cleanup(func() {
+		for _, server := range cluster.servers {
+			server.Crash(context.Background(), &emptypb.Empty{})
+		}
+	})
+	return cluster
+}
+
+func (c *testRaftCluster) states(t *testing.T) []*RaftInternalState {
+	states := make([]*RaftInternalState, len(c.raftClients))
+	for i, client := range c.raftClients {
+		state, err := client.GetInternalState(context.Background(), &emptypb.Empty{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		states[i] = state
+	}
+	return states
+}
+
+// waitForLeader waits until exactly one of the metastores that aren't crashed leads, and returns it
+func (c *testRaftCluster) waitForLeader(t *testing.T, crashed ...int) int {
+	isCrashed := map[int]bool{}
+	for _, i := range crashed {
+		isCrashed[i] = true
+	}
+	leader := -1
+	waitFor(t, "a single leader", func() bool {
+		leaders := []int{}
+		for i, state := range c.states(t) {
+			if state.IsLeader && !isCrashed[i] {
+				leaders = append(leaders, i)
+			}
+		}
+		if len(leaders) != 1 {
+			return false
+		}
+		leader = leaders[0]
+		return true
+	})
+	return leader
+}
+
+// waitForFile waits until the file is applied at that version on the metastore
+func (c *testRaftCluster) waitForFile(t *testing.T, server int, filename string, version int32) {
+	waitFor(t, fmt.Sprintf("%s at version %d on server %d", filename, version, server), func() bool {
+		state := c.states(t)[server]
+		fileMetaData, ok := state.MetaMap.FileInfoMap[filename]
+		return ok && fileMetaData.Version == version
+	})
+}
+
+func (c *testRaftCluster) updateFile(t *testing.T, server int, filename string, version int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), TEST_RAFT_TIMEOUT)
+	defer cancel()
+	fileMetaData := &FileMetaData{Filename: filename, Version: version, BlockHashList: []string{filename}}
+	v, err := c.metaClients[server].UpdateFile(ctx, fileMetaData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != version {
+		t.Fatalf("%s: got version %d, want %d", filename, v.Version, version)
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	deadline := time.Now().Add(TEST_RAFT_TIMEOUT)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for", what)
+		}
+		time.Sleep(RAFT_TICK_INTERVAL)
+	}
+}
+
+func TestRaftElection(t *testing.T) {
+	cluster := startTestRaftCluster(t, int64(SNAPSHOT_INTERVAL))
+	leader := cluster.waitForLeader(t)
+
+	// the followers turn the client away
+	for i, client := range cluster.metaClients {
+		_, err := client.GetFileInfoMap(context.Background(), &emptypb.Empty{})
+		if i == leader && err != nil {
+			t.Fatal(err)
+		}
+		if i != leader && err == nil {
+			t.Errorf("follower %d served GetFileInfoMap", i)
+		}
+	}
+
+	// every metastore agrees on the leader's term
+	term := cluster.states(t)[leader].Term
+	waitFor(t, "the followers to reach the leader's term", func() bool {
+		for _, state := range cluster.states(t) {
+			if state.Term != term {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestRaftReplication(t *testing.T) {
+	cluster := startTestRaftCluster(t, int64(SNAPSHOT_INTERVAL))
+	leader := cluster.waitForLeader(t)
+
+	cluster.updateFile(t, leader, "a.txt", 1)
+	cluster.updateFile(t, leader, "a.txt", 2)
+	cluster.updateFile(t, leader, "b.txt", 1)
+	for i := range cluster.servers {
+		cluster.waitForFile(t, i, "a.txt", 2)
+		cluster.waitForFile(t, i, "b.txt", 1)
+	}
+
+	// the no-op and the three updates, in the same order everywhere
+	states := cluster.states(t)
+	for i, state := range states {
+		if len(state.Log) != 4 {
+			t.Fatalf("server %d has %d entries, want 4", i, len(state.Log))
+		}
+		for j, entry := range state.Log {
+			if entry.Term != states[leader].Log[j].Term || entry.FileMetaData.GetVersion() != states[leader].Log[j].FileMetaData.GetVersion() {
+				t.Errorf("server %d disagrees with the leader on entry %d", i, j)
+			}
+		}
+	}
+}
+
+func TestRaftCommitAfterLeaderCrash(t *testing.T) {
+	cluster := startTestRaftCluster(t, int64(SNAPSHOT_INTERVAL))
+	oldLeader := cluster.waitForLeader(t)
+	cluster.updateFile(t, oldLeader, "a.txt", 1)
+
+	if _, err := cluster.raftClients[oldLeader].Crash(context.Background(), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	if state := cluster.states(t)[oldLeader]; state.IsLeader {
+		t.Fatal("the crashed leader still leads")
+	}
+	newLeader := cluster.waitForLeader(t, oldLeader)
+	if newLeader == oldLeader {
+		t.Fatal("the crashed leader was elected again")
+	}
+
+	// the remaining two metastores are a majority, they commit on their own
+	cluster.updateFile(t, newLeader, "a.txt", 2)
+	cluster.updateFile(t, newLeader, "b.txt", 1)
+
+	if _, err := cluster.raftClients[oldLeader].Restore(context.Background(), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	cluster.waitForFile(t, oldLeader, "a.txt", 2)
+	cluster.waitForFile(t, oldLeader, "b.txt", 1)
+	if leader := cluster.waitForLeader(t); leader != newLeader {
+		t.Errorf("server %d took over after its restore, want %d to keep leading", leader, newLeader)
+	}
+}
+
+func TestRaftSnapshot(t *testing.T) {
+	cluster := startTestRaftCluster(t, 2)
+	leader := cluster.waitForLeader(t)
+	follower := (leader + 1) % TEST_RAFT_SERVERS
+
+	if _, err := cluster.raftClients[follower].Crash(context.Background(), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	for version := int32(1); version <= 5; version++ {
+		cluster.updateFile(t, leader, "a.txt", version)
+	}
+	cluster.updateFile(t, leader, "b.txt", 1)
+	if state := cluster.states(t)[leader]; len(state.Log) >= 7 {
+		t.Fatalf("the leader kept %d entries, the log was not compacted", len(state.Log))
+	}
+
+	// the follower missed entries the leader no longer has, it catches up from the snapshot
+	if _, err := cluster.raftClients[follower].Restore(context.Background(), &emptypb.Empty{}); err != nil {
+		t.Fatal(err)
+	}
+	cluster.waitForFile(t, follower, "a.txt", 5)
+	cluster.waitForFile(t, follower, "b.txt", 1)
+
+	// and takes new entries on top of it
+	cluster.updateFile(t, leader, "c.txt", 1)
+	cluster.waitForFile(t, follower, "c.txt", 1)
+}
@@ -0,0 +1,181 @@
+package surfstore
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/*
+server side:
+when a blockstore joins or leaves, the ring changes the owners of some blocks. The rebalancer asks every blockstore
+of the old ring which blocks it has, finds the blocks whose replica set changed, and copies each of them from a server
+that has it to the servers that newly own it, using the same GetBlockHashes/HasBlocks/GetBlock/PutBlock calls as the client.
+Blocks whose owners didn't change are never touched. If old servers can't be reached and some part of the ring has all
+its replicas on them, the blocks there would be lost, so the migration fails and the old ring stays. Once the new ring is
+in place, PruneBlocks deletes the copies left on servers that no longer own a block, but only after checking that every
+new owner has it. A removed server is left alone, it is going away anyway.
+A client that got its block map before the switch can still write to an old owner after the second migration, those
+blocks would never reach their new owners. The ring version catches that: the metastore refuses its UpdateFile and the
+client places the blocks again.
+*/
+
+// MigrateBlocks copies every block whose ownership differs between oldRing and newRing to its new owners,
+// and returns how many blocks were copied. It is idempotent, running it twice only copies what is still missing.
+func MigrateBlocks(oldRing *ConsistentHashRing, newRing *ConsistentHashRing) (int, error) {
+	client := RPCClient{Options: DefaultRPCOptions(), conns: NewConnPool()} // only the blockstore half of the client is used
+	defer client.Close()
+
+	// newOwner : hash : a server we can read the block from
+	toCopy := make(map[string]map[string]string)
+	seen := make(map[string]bool)
+	unreachable := make(map[string]bool)
+	for _, source := range oldRing.GetServerAddrs() {
+		var hashes []string
+		if err := client.GetBlockHashes(source, &hashes); err != nil {
+			// an unreachable (e.g. removed) server is fine as long as the other replicas have its blocks
+			log.Println("[Rebalancer] could not list blocks on", source, ":", err)
+			unreachable[source] = true
+			continue
+		}
+
+		for _, hash := range hashes {
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			oldOwners := make(map[string]bool)
+			for _, server := range oldRing.GetResponsibleServers(hash) {
+				oldOwners[server] = true
+			}
+			for _, server := range newRing.GetResponsibleServers(hash) {
+				if oldOwners[server] || server == source {
+					continue
+				}
+				if toCopy[server] == nil {
+					toCopy[server] = make(map[string]string)
+				}
+				toCopy[server][hash] = source
+			}
+		}
+	}
+
+	if len(unreachable) > 0 && hasUnreachableArc(oldRing, unreachable) {
+		servers := []string{}
+		for server := range unreachable {
+			servers = append(servers, server)
+		}
+		return 0, fmt.Errorf("could not reach blockstore %s, some blocks have no other replica to copy them from", strings.Join(servers, ", "))
+	}
+
+	copied := 0
+	for target, sources := range toCopy {
+		candidates := []string{}
+		for hash := range sources {
+			candidates = append(candidates, hash)
+		}
+		var present []string
+		if err := client.HasBlocks(candidates, target, &present); err != nil {
+			return copied, fmt.Errorf("could not reach blockstore %s: %v", target, err)
+		}
+		for _, hash := range present {
+			delete(sources, hash)
+		}
+
+		for hash, source := range sources {
+			var block Block
+			if err := client.GetBlock(hash, source, &block); err != nil {
+				return copied, fmt.Errorf("could not get block %s from %s: %v", hash, source, err)
+			}
+			block.Hash = hash
+			var succ bool
+			if err := client.PutBlock(&block, target, &succ); err != nil || !succ {
+				return copied, fmt.Errorf("could not put block %s to %s: %v", hash, target, err)
+			}
+			copied++
+		}
+	}
+
+	log.Println("[Rebalancer] copied", copied, "blocks to their new owners")
+	return copied, nil
+}
+
+// hasUnreachableArc tells whether some part of the ring has all of its replicas on unreachable servers. The blocks
+// between two successive virtual nodes share a replica set, the one GetResponsibleServers returns for the first node.
+func hasUnreachableArc(ring *ConsistentHashRing, unreachable map[string]bool) bool {
+	for _, token := range ring.tokens {
+		lost := true
+		for _, server := range ring.GetResponsibleServers(token) {
+			if !unreachable[server] {
+				lost = false
+				break
+			}
+		}
+		if lost {
+			return true
+		}
+	}
+	return false
+}
+
+// PruneBlocks deletes the blocks that servers of newRing hold without owning them (they owned them in oldRing),
+// once every owner in newRing has them, and returns how many copies were deleted. Servers that only oldRing has are
+// not touched.
+func PruneBlocks(oldRing *ConsistentHashRing, newRing *ConsistentHashRing) (int, error) {
+	client := RPCClient{Options: DefaultRPCOptions(), conns: NewConnPool()} // only the blockstore half of the client is used
+	defer client.Close()
+
+	deleted := 0
+	for _, server := range newRing.GetServerAddrs() {
+		var hashes []string
+		if err := client.GetBlockHashes(server, &hashes); err != nil {
+			return deleted, fmt.Errorf("could not list blocks on %s: %v", server, err)
+		}
+
+		// owner : the blocks of server it has to have before server may drop them
+		toCheck := make(map[string][]string)
+		for _, hash := range hashes {
+			owners := newRing.GetResponsibleServers(hash)
+			if containsString(owners, server) {
+				continue
+			}
+			for _, owner := range owners {
+				toCheck[owner] = append(toCheck[owner], hash)
+			}
+		}
+		if len(toCheck) == 0 {
+			continue
+		}
+
+		// hash : how many of its owners have it
+		confirmed := make(map[string]int)
+		for owner, ownerHashes := range toCheck {
+			var present []string
+			if err := client.HasBlocks(ownerHashes, owner, &present); err != nil {
+				return deleted, fmt.Errorf("could not reach blockstore %s: %v", owner, err)
+			}
+			for _, hash := range present {
+				confirmed[hash]++
+			}
+		}
+		stale := []string{}
+		for hash, count := range confirmed {
+			if count == len(newRing.GetResponsibleServers(hash)) {
+				stale = append(stale, hash)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+
+		var deletedHashes []string
+		if err := client.DeleteBlocks(stale, server, &deletedHashes); err != nil {
+			return deleted, fmt.Errorf("could not delete blocks on %s: %v", server, err)
+		}
+		deleted += len(deletedHashes)
+	}
+
+	log.Println("[Rebalancer] deleted", deleted, "blocks from servers that no longer own them")
+	return deleted, nil
+}
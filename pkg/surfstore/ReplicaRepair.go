@@ -0,0 +1,126 @@
+package surfstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+client side:
+an upload is accepted once a write quorum of the replicas of every block has it, so a replica that was down or failed
+the write is left without the block. putBlockBatch records every such block with the replicas that missed it, and at
+the end of the sync repairReplicas copies it to them from a replica that has it. What still can't be repaired (the
+replica is still down) is kept in index.db and tried again on the next sync, so the missing copies are made as soon
+as the replica is back.
+*/
+
+// underReplicated collects the blocks that reached a write quorum but not all of their replicas
+type underReplicated struct {
+	mu      sync.Mutex
+	missing map[string][]string // hash : replicas that don't have it
+}
+
+func newUnderReplicated(missing map[string][]string) *underReplicated {
+	if missing == nil {
+		missing = make(map[string][]string)
+	}
+	return &underReplicated{missing: missing}
+}
+
+func (u *underReplicated) add(hash string, blockStoreAddr string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !containsString(u.missing[hash], blockStoreAddr) {
+		u.missing[hash] = append(u.missing[hash], blockStoreAddr)
+	}
+}
+
+// repairReplicas copies every under-replicated block to the replicas missing it and keeps only what it couldn't copy.
+// The replica sets are looked up again, a replica that no longer owns the block (the ring changed) is not repaired.
+func repairReplicas(client RPCClient, u *underReplicated) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.missing) == 0 {
+		return
+	}
+
+	hashes := []string{}
+	for hash := range u.missing {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	m := make(map[string][]string)
+	if err := client.GetBlockStoreMap(hashes, &m); err != nil {
+		fmt.Println("Could not get blockStoreAddr: ", err)
+		return
+	}
+	replicas := getBlockReplicas(m)
+
+	// blockstore : the blocks it is missing
+	targets := make(map[string][]string)
+	for _, hash := range hashes {
+		for _, blockStoreAddr := range u.missing[hash] {
+			if containsString(replicas[hash], blockStoreAddr) {
+				targets[blockStoreAddr] = append(targets[blockStoreAddr], hash)
+			}
+		}
+		delete(u.missing, hash)
+	}
+
+	repaired := 0
+	for target, targetHashes := range targets {
+		blockStoreAddr := strings.ReplaceAll(target, "blockstore", "")
+		var present []string
+		if err := client.HasBlocks(targetHashes, blockStoreAddr, &present); err != nil {
+			// still down, try again next time
+			for _, hash := range targetHashes {
+				u.missing[hash] = append(u.missing[hash], target)
+			}
+			continue
+		}
+		has := make(map[string]bool)
+		for _, hash := range present {
+			has[hash] = true
+		}
+		for _, hash := range targetHashes {
+			if has[hash] {
+				continue
+			}
+			if err := copyBlock(client, hash, replicas[hash], target); err != nil {
+				fmt.Println("Could not repair replica: ", err)
+				u.missing[hash] = append(u.missing[hash], target)
+				continue
+			}
+			repaired++
+		}
+	}
+	if repaired > 0 || len(u.missing) > 0 {
+		fmt.Printf("Copied %d blocks to replicas that missed them, %d blocks are still under-replicated\n", repaired, len(u.missing))
+	}
+}
+
+// copyBlock reads the block from the first of its replicas that has a good copy and stores it on target
+func copyBlock(client RPCClient, hash string, replicas []string, target string) error {
+	for _, source := range replicas {
+		if source == target {
+			continue
+		}
+		var block Block
+		// GetBlock checks the hash, a corrupted copy is skipped
+		if err := client.GetBlock(hash, strings.ReplaceAll(source, "blockstore", ""), &block); err != nil {
+			continue
+		}
+		block.Hash = hash
+		var succ bool
+		if err := client.PutBlock(&block, strings.ReplaceAll(target, "blockstore", ""), &succ); err != nil {
+			return fmt.Errorf("could not put block %s to %s: %v", hash, target, err)
+		}
+		if !succ {
+			return fmt.Errorf("could not put block %s to %s", hash, target)
+		}
+		return nil
+	}
+	return fmt.Errorf("no replica has a good copy of block %s", hash)
+}
@@ -21,6 +21,56 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// how blockData is compressed, the block hash is always computed over the uncompressed data
+type Codec int32
+
+const (
+	Codec_NONE Codec = 0
+	Codec_GZIP Codec = 1
+	Codec_ZSTD Codec = 2
+)
+
+// Enum value maps for Codec.
+var (
+	Codec_name = map[int32]string{
+		0: "NONE",
+		1: "GZIP",
+		2: "ZSTD",
+	}
+	Codec_value = map[string]int32{
+		"NONE": 0,
+		"GZIP": 1,
+		"ZSTD": 2,
+	}
+)
+
+func (x Codec) Enum() *Codec {
+	p := new(Codec)
+	*p = x
+	return p
+}
+
+func (x Codec) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Codec) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_surfstore_SurfStore_proto_enumTypes[0].Descriptor()
+}
+
+func (Codec) Type() protoreflect.EnumType {
+	return &file_pkg_surfstore_SurfStore_proto_enumTypes[0]
+}
+
+func (x Codec) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Codec.Descriptor instead.
+func (Codec) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{0}
+}
+
 type BlockHash struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -121,7 +171,9 @@ type Block struct {
 	unknownFields protoimpl.UnknownFields
 
 	BlockData []byte `protobuf:"bytes,1,opt,name=blockData,proto3" json:"blockData,omitempty"`
-	BlockSize int32  `protobuf:"varint,2,opt,name=blockSize,proto3" json:"blockSize,omitempty"`
+	BlockSize int32  `protobuf:"varint,2,opt,name=blockSize,proto3" json:"blockSize,omitempty"` // size of the uncompressed data
+	Codec     Codec  `protobuf:"varint,3,opt,name=codec,proto3,enum=surfstore.Codec" json:"codec,omitempty"`
+	Hash      string `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"` // on PutBlock/PutBlocks: the hash the client expects, the block is rejected if it hashes to something else (unchecked if empty)
 }
 
 func (x *Block) Reset() {
@@ -170,6 +222,20 @@ func (x *Block) GetBlockSize() int32 {
 	return 0
 }
 
+func (x *Block) GetCodec() Codec {
+	if x != nil {
+		return x.Codec
+	}
+	return Codec_NONE
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
 type Success struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -225,6 +291,9 @@ type FileMetaData struct {
 	Filename      string   `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
 	Version       int32    `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	BlockHashList []string `protobuf:"bytes,3,rep,name=blockHashList,proto3" json:"blockHashList,omitempty"`
+	// on UpdateFile: the version of the ring the blocks were placed with (see BlockStoreMap), the update is refused
+	// with ABORTED if the ring changed since, so the client places the blocks again. Unchecked if 0.
+	RingVersion int64 `protobuf:"varint,4,opt,name=ringVersion,proto3" json:"ringVersion,omitempty"`
 }
 
 func (x *FileMetaData) Reset() {
@@ -280,6 +349,13 @@ func (x *FileMetaData) GetBlockHashList() []string {
 	return nil
 }
 
+func (x *FileMetaData) GetRingVersion() int64 {
+	if x != nil {
+		return x.RingVersion
+	}
+	return 0
+}
+
 type FileInfoMap struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -380,6 +456,7 @@ type BlockStoreMap struct {
 	unknownFields protoimpl.UnknownFields
 
 	BlockStoreMap map[string]*BlockHashes `protobuf:"bytes,1,rep,name=blockStoreMap,proto3" json:"blockStoreMap,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	RingVersion   int64                   `protobuf:"varint,2,opt,name=ringVersion,proto3" json:"ringVersion,omitempty"` // the version of the ring the blocks were placed with
 }
 
 func (x *BlockStoreMap) Reset() {
@@ -421,6 +498,13 @@ func (x *BlockStoreMap) GetBlockStoreMap() map[string]*BlockHashes {
 	return nil
 }
 
+func (x *BlockStoreMap) GetRingVersion() int64 {
+	if x != nil {
+		return x.RingVersion
+	}
+	return 0
+}
+
 type BlockStoreAddrs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -468,233 +552,2156 @@ func (x *BlockStoreAddrs) GetBlockStoreAddrs() []string {
 	return nil
 }
 
-var File_pkg_surfstore_SurfStore_proto protoreflect.FileDescriptor
+type BlockStoreChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_pkg_surfstore_SurfStore_proto_rawDesc = []byte{
-	0x0a, 0x1d, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2f,
-	0x53, 0x75, 0x72, 0x66, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x09, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74,
-	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1f, 0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
-	0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x25, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x61, 0x73, 0x68, 0x65,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22,
-	0x43, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x62, 0x6c, 0x6f, 0x63,
-	0x6b, 0x44, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f,
-	0x63, 0x6b, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53,
-	0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
-	0x53, 0x69, 0x7a, 0x65, 0x22, 0x1d, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12,
-	0x12, 0x0a, 0x04, 0x66, 0x6c, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x66,
-	0x6c, 0x61, 0x67, 0x22, 0x6a, 0x0a, 0x0c, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44,
-	0x61, 0x74, 0x61, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12,
-	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x0d, 0x62, 0x6c, 0x6f,
-	0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x4c, 0x69, 0x73, 0x74, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x0d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x4c, 0x69, 0x73, 0x74, 0x22,
-	0xb1, 0x01, 0x0a, 0x0b, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x12,
-	0x49, 0x0a, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
-	0x2e, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x2e, 0x46, 0x69, 0x6c,
-	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x66,
-	0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x1a, 0x57, 0x0a, 0x10, 0x46, 0x69,
-	0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
-	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
-	0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65,
-	0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
-	0x02, 0x38, 0x01, 0x22, 0x23, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18,
-	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xbc, 0x01, 0x0a, 0x0d, 0x42, 0x6c, 0x6f,
-	0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x12, 0x51, 0x0a, 0x0d, 0x62, 0x6c,
-	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x2b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d,
-	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x1a, 0x58, 0x0a,
-	0x12, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
-	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x52, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x3b, 0x0a, 0x0f, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
-	0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x62, 0x6c,
-	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x0f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41,
-	0x64, 0x64, 0x72, 0x73, 0x32, 0xf9, 0x01, 0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74,
-	0x6f, 0x72, 0x65, 0x12, 0x34, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
-	0x14, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
-	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x08, 0x50, 0x75, 0x74,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
-	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74,
-	0x6f, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x3d, 0x0a,
-	0x09, 0x48, 0x61, 0x73, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x73, 0x75, 0x72,
-	0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
-	0x65, 0x73, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0e,
-	0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x16,
-	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
-	0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x00,
-	0x32, 0xa0, 0x02, 0x0a, 0x09, 0x4d, 0x65, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x42,
-	0x0a, 0x0e, 0x47, 0x65, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70,
-	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
-	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70,
-	0x22, 0x00, 0x12, 0x3b, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65,
-	0x12, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c,
-	0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66,
-	0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12,
-	0x46, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65,
-	0x4d, 0x61, 0x70, 0x12, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x1a, 0x18, 0x2e, 0x73, 0x75,
-	0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f,
-	0x72, 0x65, 0x4d, 0x61, 0x70, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x16, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1a, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
-	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72,
-	0x73, 0x22, 0x00, 0x42, 0x1c, 0x5a, 0x1a, 0x63, 0x73, 0x65, 0x32, 0x32, 0x34, 0x2f, 0x70, 0x72,
-	0x6f, 0x6a, 0x34, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
-	0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	Addr   string `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	Weight int32  `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
 }
 
-var (
-	file_pkg_surfstore_SurfStore_proto_rawDescOnce sync.Once
-	file_pkg_surfstore_SurfStore_proto_rawDescData = file_pkg_surfstore_SurfStore_proto_rawDesc
-)
+func (x *BlockStoreChange) Reset() {
+	*x = BlockStoreChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_pkg_surfstore_SurfStore_proto_rawDescGZIP() []byte {
-	file_pkg_surfstore_SurfStore_proto_rawDescOnce.Do(func() {
-		file_pkg_surfstore_SurfStore_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_surfstore_SurfStore_proto_rawDescData)
-	})
-	return file_pkg_surfstore_SurfStore_proto_rawDescData
+func (x *BlockStoreChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_pkg_surfstore_SurfStore_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
-var file_pkg_surfstore_SurfStore_proto_goTypes = []interface{}{
-	(*BlockHash)(nil),       // 0: surfstore.BlockHash
-	(*BlockHashes)(nil),     // 1: surfstore.BlockHashes
-	(*Block)(nil),           // 2: surfstore.Block
-	(*Success)(nil),         // 3: surfstore.Success
-	(*FileMetaData)(nil),    // 4: surfstore.FileMetaData
-	(*FileInfoMap)(nil),     // 5: surfstore.FileInfoMap
-	(*Version)(nil),         // 6: surfstore.Version
-	(*BlockStoreMap)(nil),   // 7: surfstore.BlockStoreMap
-	(*BlockStoreAddrs)(nil), // 8: surfstore.BlockStoreAddrs
-	nil,                     // 9: surfstore.FileInfoMap.FileInfoMapEntry
-	nil,                     // 10: surfstore.BlockStoreMap.BlockStoreMapEntry
-	(*emptypb.Empty)(nil),   // 11: google.protobuf.Empty
+func (*BlockStoreChange) ProtoMessage() {}
+
+func (x *BlockStoreChange) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_pkg_surfstore_SurfStore_proto_depIdxs = []int32{
-	9,  // 0: surfstore.FileInfoMap.fileInfoMap:type_name -> surfstore.FileInfoMap.FileInfoMapEntry
-	10, // 1: surfstore.BlockStoreMap.blockStoreMap:type_name -> surfstore.BlockStoreMap.BlockStoreMapEntry
-	4,  // 2: surfstore.FileInfoMap.FileInfoMapEntry.value:type_name -> surfstore.FileMetaData
-	1,  // 3: surfstore.BlockStoreMap.BlockStoreMapEntry.value:type_name -> surfstore.BlockHashes
-	0,  // 4: surfstore.BlockStore.GetBlock:input_type -> surfstore.BlockHash
-	2,  // 5: surfstore.BlockStore.PutBlock:input_type -> surfstore.Block
-	1,  // 6: surfstore.BlockStore.HasBlocks:input_type -> surfstore.BlockHashes
-	11, // 7: surfstore.BlockStore.GetBlockHashes:input_type -> google.protobuf.Empty
-	11, // 8: surfstore.MetaStore.GetFileInfoMap:input_type -> google.protobuf.Empty
-	4,  // 9: surfstore.MetaStore.UpdateFile:input_type -> surfstore.FileMetaData
-	1,  // 10: surfstore.MetaStore.GetBlockStoreMap:input_type -> surfstore.BlockHashes
-	11, // 11: surfstore.MetaStore.GetBlockStoreAddrs:input_type -> google.protobuf.Empty
-	2,  // 12: surfstore.BlockStore.GetBlock:output_type -> surfstore.Block
-	3,  // 13: surfstore.BlockStore.PutBlock:output_type -> surfstore.Success
-	1,  // 14: surfstore.BlockStore.HasBlocks:output_type -> surfstore.BlockHashes
-	1,  // 15: surfstore.BlockStore.GetBlockHashes:output_type -> surfstore.BlockHashes
-	5,  // 16: surfstore.MetaStore.GetFileInfoMap:output_type -> surfstore.FileInfoMap
-	6,  // 17: surfstore.MetaStore.UpdateFile:output_type -> surfstore.Version
-	7,  // 18: surfstore.MetaStore.GetBlockStoreMap:output_type -> surfstore.BlockStoreMap
-	8,  // 19: surfstore.MetaStore.GetBlockStoreAddrs:output_type -> surfstore.BlockStoreAddrs
-	12, // [12:20] is the sub-list for method output_type
-	4,  // [4:12] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+
+// Deprecated: Use BlockStoreChange.ProtoReflect.Descriptor instead.
+func (*BlockStoreChange) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{9}
 }
 
-func init() { file_pkg_surfstore_SurfStore_proto_init() }
-func file_pkg_surfstore_SurfStore_proto_init() {
-	if File_pkg_surfstore_SurfStore_proto != nil {
-		return
+func (x *BlockStoreChange) GetAddr() string {
+	if x != nil {
+		return x.Addr
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_pkg_surfstore_SurfStore_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BlockHash); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BlockHashes); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Block); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Success); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FileMetaData); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+	return ""
+}
+
+func (x *BlockStoreChange) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type BlockStoreRing struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Weights           map[string]int32 `protobuf:"bytes,1,rep,name=weights,proto3" json:"weights,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	VirtualNodes      int32            `protobuf:"varint,2,opt,name=virtualNodes,proto3" json:"virtualNodes,omitempty"`
+	ReplicationFactor int32            `protobuf:"varint,3,opt,name=replicationFactor,proto3" json:"replicationFactor,omitempty"`
+	Version           int64            `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"` // goes up with every membership change
+}
+
+func (x *BlockStoreRing) Reset() {
+	*x = BlockStoreRing{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockStoreRing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockStoreRing) ProtoMessage() {}
+
+func (x *BlockStoreRing) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FileInfoMap); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockStoreRing.ProtoReflect.Descriptor instead.
+func (*BlockStoreRing) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BlockStoreRing) GetWeights() map[string]int32 {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+func (x *BlockStoreRing) GetVirtualNodes() int32 {
+	if x != nil {
+		return x.VirtualNodes
+	}
+	return 0
+}
+
+func (x *BlockStoreRing) GetReplicationFactor() int32 {
+	if x != nil {
+		return x.ReplicationFactor
+	}
+	return 0
+}
+
+func (x *BlockStoreRing) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type MetaLogEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileMetaData   *FileMetaData   `protobuf:"bytes,1,opt,name=fileMetaData,proto3" json:"fileMetaData,omitempty"`
+	BlockStoreRing *BlockStoreRing `protobuf:"bytes,2,opt,name=blockStoreRing,proto3" json:"blockStoreRing,omitempty"`
+	RaftState      *RaftHardState  `protobuf:"bytes,3,opt,name=raftState,proto3" json:"raftState,omitempty"` // raft: the term or the vote changed
+	RaftLog        *RaftLogAppend  `protobuf:"bytes,4,opt,name=raftLog,proto3" json:"raftLog,omitempty"`     // raft: the log from fromIndex on was replaced
+	Epoch          string          `protobuf:"bytes,5,opt,name=epoch,proto3" json:"epoch,omitempty"`         // the metastore started a new history of seqs
+	EncryptionSalt []byte          `protobuf:"bytes,6,opt,name=encryptionSalt,proto3" json:"encryptionSalt,omitempty"`
+}
+
+func (x *MetaLogEntry) Reset() {
+	*x = MetaLogEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetaLogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetaLogEntry) ProtoMessage() {}
+
+func (x *MetaLogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-		file_pkg_surfstore_SurfStore_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetaLogEntry.ProtoReflect.Descriptor instead.
+func (*MetaLogEntry) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MetaLogEntry) GetFileMetaData() *FileMetaData {
+	if x != nil {
+		return x.FileMetaData
+	}
+	return nil
+}
+
+func (x *MetaLogEntry) GetBlockStoreRing() *BlockStoreRing {
+	if x != nil {
+		return x.BlockStoreRing
+	}
+	return nil
+}
+
+func (x *MetaLogEntry) GetRaftState() *RaftHardState {
+	if x != nil {
+		return x.RaftState
+	}
+	return nil
+}
+
+func (x *MetaLogEntry) GetRaftLog() *RaftLogAppend {
+	if x != nil {
+		return x.RaftLog
+	}
+	return nil
+}
+
+func (x *MetaLogEntry) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+func (x *MetaLogEntry) GetEncryptionSalt() []byte {
+	if x != nil {
+		return x.EncryptionSalt
+	}
+	return nil
+}
+
+type MetaSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileInfoMap    map[string]*FileMetaData `protobuf:"bytes,1,rep,name=fileInfoMap,proto3" json:"fileInfoMap,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	BlockStoreRing *BlockStoreRing          `protobuf:"bytes,2,opt,name=blockStoreRing,proto3" json:"blockStoreRing,omitempty"`
+	Seq            int64                    `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	FileSeqs       map[string]int64         `protobuf:"bytes,4,rep,name=fileSeqs,proto3" json:"fileSeqs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	RaftState      *RaftHardState           `protobuf:"bytes,5,opt,name=raftState,proto3" json:"raftState,omitempty"`
+	RaftLog        []*UpdateOperation       `protobuf:"bytes,6,rep,name=raftLog,proto3" json:"raftLog,omitempty"`
+	Epoch          string                   `protobuf:"bytes,7,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	EncryptionSalt []byte                   `protobuf:"bytes,8,opt,name=encryptionSalt,proto3" json:"encryptionSalt,omitempty"`
+	// raft: the index of the first entry of raftLog, the entries before it are compacted into the state above
+	RaftLogStart     int64 `protobuf:"varint,9,opt,name=raftLogStart,proto3" json:"raftLogStart,omitempty"`
+	RaftSnapshotTerm int64 `protobuf:"varint,10,opt,name=raftSnapshotTerm,proto3" json:"raftSnapshotTerm,omitempty"` // raft: the term of the entry at raftLogStart-1
+}
+
+func (x *MetaSnapshot) Reset() {
+	*x = MetaSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetaSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetaSnapshot) ProtoMessage() {}
+
+func (x *MetaSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetaSnapshot.ProtoReflect.Descriptor instead.
+func (*MetaSnapshot) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MetaSnapshot) GetFileInfoMap() map[string]*FileMetaData {
+	if x != nil {
+		return x.FileInfoMap
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetBlockStoreRing() *BlockStoreRing {
+	if x != nil {
+		return x.BlockStoreRing
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MetaSnapshot) GetFileSeqs() map[string]int64 {
+	if x != nil {
+		return x.FileSeqs
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetRaftState() *RaftHardState {
+	if x != nil {
+		return x.RaftState
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetRaftLog() []*UpdateOperation {
+	if x != nil {
+		return x.RaftLog
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+func (x *MetaSnapshot) GetEncryptionSalt() []byte {
+	if x != nil {
+		return x.EncryptionSalt
+	}
+	return nil
+}
+
+func (x *MetaSnapshot) GetRaftLogStart() int64 {
+	if x != nil {
+		return x.RaftLogStart
+	}
+	return 0
+}
+
+func (x *MetaSnapshot) GetRaftSnapshotTerm() int64 {
+	if x != nil {
+		return x.RaftSnapshotTerm
+	}
+	return 0
+}
+
+type RaftHardState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term     int64 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	VotedFor int64 `protobuf:"varint,2,opt,name=votedFor,proto3" json:"votedFor,omitempty"`
+}
+
+func (x *RaftHardState) Reset() {
+	*x = RaftHardState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RaftHardState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftHardState) ProtoMessage() {}
+
+func (x *RaftHardState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftHardState.ProtoReflect.Descriptor instead.
+func (*RaftHardState) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RaftHardState) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftHardState) GetVotedFor() int64 {
+	if x != nil {
+		return x.VotedFor
+	}
+	return 0
+}
+
+type RaftLogAppend struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromIndex int64              `protobuf:"varint,1,opt,name=fromIndex,proto3" json:"fromIndex,omitempty"`
+	Entries   []*UpdateOperation `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (x *RaftLogAppend) Reset() {
+	*x = RaftLogAppend{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RaftLogAppend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftLogAppend) ProtoMessage() {}
+
+func (x *RaftLogAppend) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftLogAppend.ProtoReflect.Descriptor instead.
+func (*RaftLogAppend) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RaftLogAppend) GetFromIndex() int64 {
+	if x != nil {
+		return x.FromIndex
+	}
+	return 0
+}
+
+func (x *RaftLogAppend) GetEntries() []*UpdateOperation {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// seqs only mean something within one epoch of the metastore, a request with another epoch is refused with
+// OutOfRange, an empty epoch is accepted as any
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SinceSeq int64  `protobuf:"varint,1,opt,name=sinceSeq,proto3" json:"sinceSeq,omitempty"`
+	Epoch    string `protobuf:"bytes,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchRequest) GetSinceSeq() int64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type ChangesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SinceSeq int64  `protobuf:"varint,1,opt,name=sinceSeq,proto3" json:"sinceSeq,omitempty"`
+	Limit    int32  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Epoch    string `protobuf:"bytes,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+}
+
+func (x *ChangesRequest) Reset() {
+	*x = ChangesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangesRequest) ProtoMessage() {}
+
+func (x *ChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangesRequest.ProtoReflect.Descriptor instead.
+func (*ChangesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChangesRequest) GetSinceSeq() int64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+func (x *ChangesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ChangesRequest) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type FileChanges struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FileMetaData []*FileMetaData `protobuf:"bytes,1,rep,name=fileMetaData,proto3" json:"fileMetaData,omitempty"`
+	Seq          int64           `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"` // pass this as sinceSeq for the next page
+	More         bool            `protobuf:"varint,3,opt,name=more,proto3" json:"more,omitempty"`
+	Epoch        string          `protobuf:"bytes,4,opt,name=epoch,proto3" json:"epoch,omitempty"` // the epoch seq belongs to
+}
+
+func (x *FileChanges) Reset() {
+	*x = FileChanges{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileChanges) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChanges) ProtoMessage() {}
+
+func (x *FileChanges) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChanges.ProtoReflect.Descriptor instead.
+func (*FileChanges) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FileChanges) GetFileMetaData() []*FileMetaData {
+	if x != nil {
+		return x.FileMetaData
+	}
+	return nil
+}
+
+func (x *FileChanges) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *FileChanges) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+func (x *FileChanges) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type FileChangeEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seq      int64  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Filename string `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Version  int32  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Deleted  bool   `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Epoch    string `protobuf:"bytes,5,opt,name=epoch,proto3" json:"epoch,omitempty"`
+}
+
+func (x *FileChangeEvent) Reset() {
+	*x = FileChangeEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChangeEvent) ProtoMessage() {}
+
+func (x *FileChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChangeEvent.ProtoReflect.Descriptor instead.
+func (*FileChangeEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *FileChangeEvent) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *FileChangeEvent) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *FileChangeEvent) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FileChangeEvent) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *FileChangeEvent) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type UpdateOperation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term           int64           `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	FileMetaData   *FileMetaData   `protobuf:"bytes,2,opt,name=fileMetaData,proto3" json:"fileMetaData,omitempty"`
+	BlockStoreRing *BlockStoreRing `protobuf:"bytes,3,opt,name=blockStoreRing,proto3" json:"blockStoreRing,omitempty"`
+	Epoch          string          `protobuf:"bytes,4,opt,name=epoch,proto3" json:"epoch,omitempty"` // raft: set on the first entry of a log, every metastore applying it takes this epoch
+	EncryptionSalt []byte          `protobuf:"bytes,5,opt,name=encryptionSalt,proto3" json:"encryptionSalt,omitempty"`
+}
+
+func (x *UpdateOperation) Reset() {
+	*x = UpdateOperation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOperation) ProtoMessage() {}
+
+func (x *UpdateOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOperation.ProtoReflect.Descriptor instead.
+func (*UpdateOperation) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UpdateOperation) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *UpdateOperation) GetFileMetaData() *FileMetaData {
+	if x != nil {
+		return x.FileMetaData
+	}
+	return nil
+}
+
+func (x *UpdateOperation) GetBlockStoreRing() *BlockStoreRing {
+	if x != nil {
+		return x.BlockStoreRing
+	}
+	return nil
+}
+
+func (x *UpdateOperation) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+func (x *UpdateOperation) GetEncryptionSalt() []byte {
+	if x != nil {
+		return x.EncryptionSalt
+	}
+	return nil
+}
+
+type AppendEntryInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term         int64              `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId     int64              `protobuf:"varint,2,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	PrevLogIndex int64              `protobuf:"varint,3,opt,name=prevLogIndex,proto3" json:"prevLogIndex,omitempty"`
+	PrevLogTerm  int64              `protobuf:"varint,4,opt,name=prevLogTerm,proto3" json:"prevLogTerm,omitempty"`
+	Entries      []*UpdateOperation `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit int64              `protobuf:"varint,6,opt,name=leaderCommit,proto3" json:"leaderCommit,omitempty"`
+}
+
+func (x *AppendEntryInput) Reset() {
+	*x = AppendEntryInput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppendEntryInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntryInput) ProtoMessage() {}
+
+func (x *AppendEntryInput) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntryInput.ProtoReflect.Descriptor instead.
+func (*AppendEntryInput) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AppendEntryInput) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntryInput) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *AppendEntryInput) GetPrevLogIndex() int64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *AppendEntryInput) GetPrevLogTerm() int64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *AppendEntryInput) GetEntries() []*UpdateOperation {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendEntryInput) GetLeaderCommit() int64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+type AppendEntryOutput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ServerId     int64 `protobuf:"varint,1,opt,name=serverId,proto3" json:"serverId,omitempty"`
+	Term         int64 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Success      bool  `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	MatchedIndex int64 `protobuf:"varint,4,opt,name=matchedIndex,proto3" json:"matchedIndex,omitempty"`
+}
+
+func (x *AppendEntryOutput) Reset() {
+	*x = AppendEntryOutput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppendEntryOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntryOutput) ProtoMessage() {}
+
+func (x *AppendEntryOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntryOutput.ProtoReflect.Descriptor instead.
+func (*AppendEntryOutput) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AppendEntryOutput) GetServerId() int64 {
+	if x != nil {
+		return x.ServerId
+	}
+	return 0
+}
+
+func (x *AppendEntryOutput) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntryOutput) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendEntryOutput) GetMatchedIndex() int64 {
+	if x != nil {
+		return x.MatchedIndex
+	}
+	return 0
+}
+
+type InstallSnapshotInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term     int64         `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId int64         `protobuf:"varint,2,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	Snapshot *MetaSnapshot `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // the applied state up to snapshot.raftLogStart-1, without the log
+}
+
+func (x *InstallSnapshotInput) Reset() {
+	*x = InstallSnapshotInput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InstallSnapshotInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallSnapshotInput) ProtoMessage() {}
+
+func (x *InstallSnapshotInput) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallSnapshotInput.ProtoReflect.Descriptor instead.
+func (*InstallSnapshotInput) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *InstallSnapshotInput) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *InstallSnapshotInput) GetLeaderId() int64 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *InstallSnapshotInput) GetSnapshot() *MetaSnapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type RequestVoteInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term         int64 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	CandidateId  int64 `protobuf:"varint,2,opt,name=candidateId,proto3" json:"candidateId,omitempty"`
+	LastLogIndex int64 `protobuf:"varint,3,opt,name=lastLogIndex,proto3" json:"lastLogIndex,omitempty"`
+	LastLogTerm  int64 `protobuf:"varint,4,opt,name=lastLogTerm,proto3" json:"lastLogTerm,omitempty"`
+}
+
+func (x *RequestVoteInput) Reset() {
+	*x = RequestVoteInput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestVoteInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteInput) ProtoMessage() {}
+
+func (x *RequestVoteInput) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteInput.ProtoReflect.Descriptor instead.
+func (*RequestVoteInput) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RequestVoteInput) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteInput) GetCandidateId() int64 {
+	if x != nil {
+		return x.CandidateId
+	}
+	return 0
+}
+
+func (x *RequestVoteInput) GetLastLogIndex() int64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *RequestVoteInput) GetLastLogTerm() int64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+type RequestVoteOutput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Term        int64 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	VoteGranted bool  `protobuf:"varint,2,opt,name=voteGranted,proto3" json:"voteGranted,omitempty"`
+}
+
+func (x *RequestVoteOutput) Reset() {
+	*x = RequestVoteOutput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestVoteOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteOutput) ProtoMessage() {}
+
+func (x *RequestVoteOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteOutput.ProtoReflect.Descriptor instead.
+func (*RequestVoteOutput) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *RequestVoteOutput) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteOutput) GetVoteGranted() bool {
+	if x != nil {
+		return x.VoteGranted
+	}
+	return false
+}
+
+type ScrubStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Enabled       bool            `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Running       bool            `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`             // a pass is in progress
+	Passes        int64           `protobuf:"varint,3,opt,name=passes,proto3" json:"passes,omitempty"`               // finished passes
+	BlocksChecked int64           `protobuf:"varint,4,opt,name=blocksChecked,proto3" json:"blocksChecked,omitempty"` // in the current pass, or the last one if none is running
+	BlocksTotal   int64           `protobuf:"varint,5,opt,name=blocksTotal,proto3" json:"blocksTotal,omitempty"`
+	BytesChecked  int64           `protobuf:"varint,6,opt,name=bytesChecked,proto3" json:"bytesChecked,omitempty"`
+	PassStarted   int64           `protobuf:"varint,7,opt,name=passStarted,proto3" json:"passStarted,omitempty"`     // unix seconds, 0 if no pass started yet
+	PassFinished  int64           `protobuf:"varint,8,opt,name=passFinished,proto3" json:"passFinished,omitempty"`   // of the last finished pass
+	NextPass      int64           `protobuf:"varint,9,opt,name=nextPass,proto3" json:"nextPass,omitempty"`           // unix seconds, 0 while a pass is running
+	CorruptBlocks []*CorruptBlock `protobuf:"bytes,10,rep,name=corruptBlocks,proto3" json:"corruptBlocks,omitempty"` // since the blockstore started, oldest first
+}
+
+func (x *ScrubStatus) Reset() {
+	*x = ScrubStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScrubStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScrubStatus) ProtoMessage() {}
+
+func (x *ScrubStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScrubStatus.ProtoReflect.Descriptor instead.
+func (*ScrubStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ScrubStatus) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *ScrubStatus) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *ScrubStatus) GetPasses() int64 {
+	if x != nil {
+		return x.Passes
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetBlocksChecked() int64 {
+	if x != nil {
+		return x.BlocksChecked
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetBlocksTotal() int64 {
+	if x != nil {
+		return x.BlocksTotal
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetBytesChecked() int64 {
+	if x != nil {
+		return x.BytesChecked
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetPassStarted() int64 {
+	if x != nil {
+		return x.PassStarted
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetPassFinished() int64 {
+	if x != nil {
+		return x.PassFinished
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetNextPass() int64 {
+	if x != nil {
+		return x.NextPass
+	}
+	return 0
+}
+
+func (x *ScrubStatus) GetCorruptBlocks() []*CorruptBlock {
+	if x != nil {
+		return x.CorruptBlocks
+	}
+	return nil
+}
+
+type CorruptBlock struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash         string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	ActualHash   string `protobuf:"bytes,2,opt,name=actualHash,proto3" json:"actualHash,omitempty"` // empty if the block couldn't even be read
+	FoundAt      int64  `protobuf:"varint,3,opt,name=foundAt,proto3" json:"foundAt,omitempty"`      // unix seconds
+	Repaired     bool   `protobuf:"varint,4,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	RepairedFrom string `protobuf:"bytes,5,opt,name=repairedFrom,proto3" json:"repairedFrom,omitempty"` // the blockstore the good copy came from
+	Error        string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`               // why it couldn't be repaired
+}
+
+func (x *CorruptBlock) Reset() {
+	*x = CorruptBlock{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CorruptBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CorruptBlock) ProtoMessage() {}
+
+func (x *CorruptBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CorruptBlock.ProtoReflect.Descriptor instead.
+func (*CorruptBlock) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CorruptBlock) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *CorruptBlock) GetActualHash() string {
+	if x != nil {
+		return x.ActualHash
+	}
+	return ""
+}
+
+func (x *CorruptBlock) GetFoundAt() int64 {
+	if x != nil {
+		return x.FoundAt
+	}
+	return 0
+}
+
+func (x *CorruptBlock) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+func (x *CorruptBlock) GetRepairedFrom() string {
+	if x != nil {
+		return x.RepairedFrom
+	}
+	return ""
+}
+
+func (x *CorruptBlock) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type EncryptionSalt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Salt []byte `protobuf:"bytes,1,opt,name=salt,proto3" json:"salt,omitempty"`
+}
+
+func (x *EncryptionSalt) Reset() {
+	*x = EncryptionSalt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncryptionSalt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncryptionSalt) ProtoMessage() {}
+
+func (x *EncryptionSalt) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncryptionSalt.ProtoReflect.Descriptor instead.
+func (*EncryptionSalt) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *EncryptionSalt) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+type CrashedState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IsCrashed bool `protobuf:"varint,1,opt,name=isCrashed,proto3" json:"isCrashed,omitempty"`
+}
+
+func (x *CrashedState) Reset() {
+	*x = CrashedState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CrashedState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrashedState) ProtoMessage() {}
+
+func (x *CrashedState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrashedState.ProtoReflect.Descriptor instead.
+func (*CrashedState) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *CrashedState) GetIsCrashed() bool {
+	if x != nil {
+		return x.IsCrashed
+	}
+	return false
+}
+
+type RaftInternalState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IsLeader    bool               `protobuf:"varint,1,opt,name=isLeader,proto3" json:"isLeader,omitempty"`
+	Term        int64              `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	CommitIndex int64              `protobuf:"varint,3,opt,name=commitIndex,proto3" json:"commitIndex,omitempty"`
+	Log         []*UpdateOperation `protobuf:"bytes,4,rep,name=log,proto3" json:"log,omitempty"`
+	MetaMap     *FileInfoMap       `protobuf:"bytes,5,opt,name=metaMap,proto3" json:"metaMap,omitempty"`
+}
+
+func (x *RaftInternalState) Reset() {
+	*x = RaftInternalState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RaftInternalState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftInternalState) ProtoMessage() {}
+
+func (x *RaftInternalState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_surfstore_SurfStore_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftInternalState.ProtoReflect.Descriptor instead.
+func (*RaftInternalState) Descriptor() ([]byte, []int) {
+	return file_pkg_surfstore_SurfStore_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RaftInternalState) GetIsLeader() bool {
+	if x != nil {
+		return x.IsLeader
+	}
+	return false
+}
+
+func (x *RaftInternalState) GetTerm() int64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftInternalState) GetCommitIndex() int64 {
+	if x != nil {
+		return x.CommitIndex
+	}
+	return 0
+}
+
+func (x *RaftInternalState) GetLog() []*UpdateOperation {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *RaftInternalState) GetMetaMap() *FileInfoMap {
+	if x != nil {
+		return x.MetaMap
+	}
+	return nil
+}
+
+var File_pkg_surfstore_SurfStore_proto protoreflect.FileDescriptor
+
+var file_pkg_surfstore_SurfStore_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2f,
+	0x53, 0x75, 0x72, 0x66, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x09, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74,
+	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1f, 0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x25, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x61, 0x73, 0x68, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22,
+	0x7f, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x44, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53,
+	0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x43, 0x6f, 0x64, 0x65, 0x63, 0x52, 0x05, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x12, 0x12, 0x0a, 0x04,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68,
+	0x22, 0x1d, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x66,
+	0x6c, 0x61, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x66, 0x6c, 0x61, 0x67, 0x22,
+	0x8c, 0x01, 0x0a, 0x0c, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x24, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48,
+	0x61, 0x73, 0x68, 0x4c, 0x69, 0x73, 0x74, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x0b,
+	0x72, 0x69, 0x6e, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0b, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xb1,
+	0x01, 0x0a, 0x0b, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x12, 0x49,
+	0x0a, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x2e, 0x46, 0x69, 0x6c, 0x65,
+	0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x66, 0x69,
+	0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x1a, 0x57, 0x0a, 0x10, 0x46, 0x69, 0x6c,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d,
+	0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x23, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xde, 0x01, 0x0a, 0x0d, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x12, 0x51, 0x0a, 0x0d, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x2b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x12, 0x20, 0x0a, 0x0b,
+	0x72, 0x69, 0x6e, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0b, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x58,
+	0x0a, 0x12, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x3b, 0x0a, 0x0f, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65,
+	0x41, 0x64, 0x64, 0x72, 0x73, 0x22, 0x3e, 0x0a, 0x10, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74,
+	0x6f, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x64, 0x64,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x64, 0x64, 0x72, 0x12, 0x16, 0x0a,
+	0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x77,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0xfa, 0x01, 0x0a, 0x0e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53,
+	0x74, 0x6f, 0x72, 0x65, 0x52, 0x69, 0x6e, 0x67, 0x12, 0x40, 0x0a, 0x07, 0x77, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x73, 0x75, 0x72, 0x66,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65,
+	0x52, 0x69, 0x6e, 0x67, 0x2e, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x07, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x69,
+	0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0c, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x2c,
+	0x0a, 0x11, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x61, 0x63,
+	0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x72, 0x65, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x3a, 0x0a, 0x0c, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0xb8, 0x02, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x61, 0x4c, 0x6f, 0x67, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x3b, 0x0a, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44,
+	0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61,
+	0x74, 0x61, 0x52, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61,
+	0x12, 0x41, 0x0a, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x69,
+	0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52,
+	0x69, 0x6e, 0x67, 0x52, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52,
+	0x69, 0x6e, 0x67, 0x12, 0x36, 0x0a, 0x09, 0x72, 0x61, 0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x2e, 0x52, 0x61, 0x66, 0x74, 0x48, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65,
+	0x52, 0x09, 0x72, 0x61, 0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x07, 0x72,
+	0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73,
+	0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67,
+	0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x07, 0x72, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67, 0x12,
+	0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x26, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x22, 0x84, 0x05,
+	0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x61, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x4a,
+	0x0a, 0x0b, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x4d, 0x65, 0x74, 0x61, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x46, 0x69, 0x6c,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x66,
+	0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x12, 0x41, 0x0a, 0x0e, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x69, 0x6e, 0x67, 0x52, 0x0e, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12,
+	0x41, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x65, 0x71, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x25, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x4d, 0x65,
+	0x74, 0x61, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x53,
+	0x65, 0x71, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x53, 0x65,
+	0x71, 0x73, 0x12, 0x36, 0x0a, 0x09, 0x72, 0x61, 0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x52, 0x61, 0x66, 0x74, 0x48, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52,
+	0x09, 0x72, 0x61, 0x66, 0x74, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x72, 0x61,
+	0x66, 0x74, 0x4c, 0x6f, 0x67, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x75,
+	0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x72, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67,
+	0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x26, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e,
+	0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x12, 0x22,
+	0x0a, 0x0c, 0x72, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x61, 0x66, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x54, 0x65, 0x72, 0x6d, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x72, 0x61,
+	0x66, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x54, 0x65, 0x72, 0x6d, 0x1a, 0x57,
+	0x0a, 0x10, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3b, 0x0a, 0x0d, 0x46, 0x69, 0x6c, 0x65, 0x53,
+	0x65, 0x71, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x3f, 0x0a, 0x0d, 0x52, 0x61, 0x66, 0x74, 0x48, 0x61, 0x72, 0x64,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x76, 0x6f, 0x74,
+	0x65, 0x64, 0x46, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x76, 0x6f, 0x74,
+	0x65, 0x64, 0x46, 0x6f, 0x72, 0x22, 0x63, 0x0a, 0x0d, 0x52, 0x61, 0x66, 0x74, 0x4c, 0x6f, 0x67,
+	0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x49,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x34, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x40, 0x0a, 0x0c, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x53, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x69,
+	0x6e, 0x63, 0x65, 0x53, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x22, 0x58, 0x0a, 0x0e,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x53, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x53, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x22, 0x86, 0x01, 0x0a, 0x0b, 0x46, 0x69, 0x6c, 0x65, 0x43,
+	0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x3b, 0x0a, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65,
+	0x74, 0x61, 0x44, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73,
+	0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74,
+	0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44,
+	0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x04, 0x6d, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x22,
+	0x89, 0x01, 0x0a, 0x0f, 0x46, 0x69, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x64,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x22, 0xe3, 0x01, 0x0a, 0x0f,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74,
+	0x65, 0x72, 0x6d, 0x12, 0x3b, 0x0a, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44,
+	0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61,
+	0x74, 0x61, 0x52, 0x0c, 0x66, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x44, 0x61, 0x74, 0x61,
+	0x12, 0x41, 0x0a, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x69,
+	0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52,
+	0x69, 0x6e, 0x67, 0x52, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52,
+	0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x26, 0x0a, 0x0e, 0x65, 0x6e, 0x63,
+	0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c,
+	0x74, 0x22, 0xe2, 0x01, 0x0a, 0x10, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6c, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x76, 0x4c, 0x6f,
+	0x67, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x70, 0x72,
+	0x65, 0x76, 0x4c, 0x6f, 0x67, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x70, 0x72,
+	0x65, 0x76, 0x4c, 0x6f, 0x67, 0x54, 0x65, 0x72, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0b, 0x70, 0x72, 0x65, 0x76, 0x4c, 0x6f, 0x67, 0x54, 0x65, 0x72, 0x6d, 0x12, 0x34, 0x0a, 0x07,
+	0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69,
+	0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x43, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x22, 0x81, 0x01, 0x0a, 0x11, 0x41, 0x70, 0x70, 0x65, 0x6e,
+	0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x64, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x65, 0x64, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x7b, 0x0a, 0x14, 0x49, 0x6e,
+	0x73, 0x74, 0x61, 0x6c, 0x6c, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x49, 0x64, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x4d, 0x65, 0x74, 0x61, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x08, 0x73,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x22, 0x8e, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x65, 0x72, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d,
+	0x12, 0x20, 0x0a, 0x0b, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x49, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65,
+	0x49, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x4c, 0x6f, 0x67, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6c, 0x61, 0x73, 0x74, 0x4c, 0x6f,
+	0x67, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x20, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x4c, 0x6f,
+	0x67, 0x54, 0x65, 0x72, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6c, 0x61, 0x73,
+	0x74, 0x4c, 0x6f, 0x67, 0x54, 0x65, 0x72, 0x6d, 0x22, 0x49, 0x0a, 0x11, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72,
+	0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x6f, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x76, 0x6f, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6e,
+	0x74, 0x65, 0x64, 0x22, 0xe6, 0x02, 0x0a, 0x0b, 0x53, 0x63, 0x72, 0x75, 0x62, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x73, 0x73, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x70, 0x61, 0x73, 0x73, 0x65, 0x73, 0x12,
+	0x24, 0x0a, 0x0d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x43, 0x68,
+	0x65, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x54,
+	0x6f, 0x74, 0x61, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x73, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x22, 0x0a, 0x0c, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x62,
+	0x79, 0x74, 0x65, 0x73, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x70,
+	0x61, 0x73, 0x73, 0x53, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0b, 0x70, 0x61, 0x73, 0x73, 0x53, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x12, 0x22, 0x0a,
+	0x0c, 0x70, 0x61, 0x73, 0x73, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0c, 0x70, 0x61, 0x73, 0x73, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65,
+	0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x73, 0x73, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x73, 0x73, 0x12, 0x3d, 0x0a,
+	0x0d, 0x63, 0x6f, 0x72, 0x72, 0x75, 0x70, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x0a,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x43, 0x6f, 0x72, 0x72, 0x75, 0x70, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x0d, 0x63,
+	0x6f, 0x72, 0x72, 0x75, 0x70, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0xb2, 0x01, 0x0a,
+	0x0c, 0x43, 0x6f, 0x72, 0x72, 0x75, 0x70, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x12, 0x0a,
+	0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73,
+	0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x63, 0x74, 0x75, 0x61, 0x6c, 0x48, 0x61, 0x73, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x63, 0x74, 0x75, 0x61, 0x6c, 0x48, 0x61, 0x73,
+	0x68, 0x12, 0x18, 0x0a, 0x07, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x41, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x41, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72,
+	0x65, 0x70, 0x61, 0x69, 0x72, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72,
+	0x65, 0x70, 0x61, 0x69, 0x72, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x72, 0x65, 0x70, 0x61, 0x69,
+	0x72, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x72,
+	0x65, 0x70, 0x61, 0x69, 0x72, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x22, 0x24, 0x0a, 0x0e, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53,
+	0x61, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x61, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x73, 0x61, 0x6c, 0x74, 0x22, 0x2c, 0x0a, 0x0c, 0x43, 0x72, 0x61, 0x73, 0x68,
+	0x65, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x73, 0x43, 0x72, 0x61,
+	0x73, 0x68, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x43, 0x72,
+	0x61, 0x73, 0x68, 0x65, 0x64, 0x22, 0xc5, 0x01, 0x0a, 0x11, 0x52, 0x61, 0x66, 0x74, 0x49, 0x6e,
+	0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69,
+	0x73, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69,
+	0x73, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x63,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x2c, 0x0a,
+	0x03, 0x6c, 0x6f, 0x67, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x75, 0x72,
+	0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x03, 0x6c, 0x6f, 0x67, 0x12, 0x30, 0x0a, 0x07, 0x6d,
+	0x65, 0x74, 0x61, 0x4d, 0x61, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73,
+	0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66,
+	0x6f, 0x4d, 0x61, 0x70, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x61, 0x4d, 0x61, 0x70, 0x2a, 0x25, 0x0a,
+	0x05, 0x43, 0x6f, 0x64, 0x65, 0x63, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00,
+	0x12, 0x08, 0x0a, 0x04, 0x47, 0x5a, 0x49, 0x50, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x5a, 0x53,
+	0x54, 0x44, 0x10, 0x02, 0x32, 0xf5, 0x03, 0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74,
+	0x6f, 0x72, 0x65, 0x12, 0x34, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
+	0x14, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x08, 0x50, 0x75, 0x74,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x3d, 0x0a,
+	0x09, 0x48, 0x61, 0x73, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x73, 0x75, 0x72,
+	0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
+	0x65, 0x73, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0e,
+	0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x00,
+	0x12, 0x39, 0x0a, 0x09, 0x50, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x10, 0x2e,
+	0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x1a,
+	0x14, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x39, 0x0a, 0x09, 0x47,
+	0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73,
+	0x1a, 0x10, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x22, 0x00, 0x30, 0x01, 0x12, 0x42, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x53, 0x63, 0x72,
+	0x75, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x63, 0x72,
+	0x75, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x2e, 0x73, 0x75, 0x72,
+	0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
+	0x65, 0x73, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x00, 0x32, 0xd1, 0x05, 0x0a,
+	0x09, 0x4d, 0x65, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x42, 0x0a, 0x0e, 0x47, 0x65,
+	0x74, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x4d, 0x61, 0x70, 0x22, 0x00, 0x12, 0x3b,
+	0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x17, 0x2e, 0x73,
+	0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74,
+	0x61, 0x44, 0x61, 0x74, 0x61, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x10, 0x47,
+	0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61, 0x70, 0x12,
+	0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x1a, 0x18, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x4d, 0x61,
+	0x70, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53,
+	0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x1a, 0x1a, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x73, 0x22, 0x00, 0x12,
+	0x42, 0x0a, 0x0d, 0x41, 0x64, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65,
+	0x12, 0x1b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x1a, 0x12, 0x2e,
+	0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x10, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x1b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x45, 0x0a, 0x0a, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1a, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69,
+	0x6c, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30,
+	0x01, 0x12, 0x46, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x53,
+	0x69, 0x6e, 0x63, 0x65, 0x12, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x16, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x46, 0x69, 0x6c, 0x65,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x22, 0x00, 0x12, 0x48, 0x0a, 0x11, 0x47, 0x65, 0x74,
+	0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x2e, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c,
+	0x74, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x11, 0x53, 0x65, 0x74, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x12, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x2e, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53,
+	0x61, 0x6c, 0x74, 0x1a, 0x19, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x61, 0x6c, 0x74, 0x22, 0x00,
+	0x32, 0xf3, 0x04, 0x0a, 0x0d, 0x52, 0x61, 0x66, 0x74, 0x53, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x45, 0x6e, 0x74, 0x72,
+	0x69, 0x65, 0x73, 0x12, 0x1b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e,
+	0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x49, 0x6e, 0x70, 0x75, 0x74,
+	0x1a, 0x1c, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x70, 0x70,
+	0x65, 0x6e, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22, 0x00,
+	0x12, 0x4a, 0x0a, 0x0b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x12,
+	0x1b, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x56, 0x6f, 0x74, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x1a, 0x1c, 0x2e, 0x73,
+	0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x56, 0x6f, 0x74, 0x65, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x0f,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6c, 0x6c, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
+	0x1f, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6c, 0x6c, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x49, 0x6e, 0x70, 0x75, 0x74,
+	0x1a, 0x1c, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x70, 0x70,
+	0x65, 0x6e, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22, 0x00,
+	0x12, 0x39, 0x0a, 0x09, 0x53, 0x65, 0x74, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x3d, 0x0a, 0x0d, 0x53,
+	0x65, 0x6e, 0x64, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x35, 0x0a, 0x05, 0x43, 0x72,
+	0x61, 0x73, 0x68, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x73, 0x75,
+	0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22,
+	0x00, 0x12, 0x37, 0x0a, 0x07, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x2e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x09, 0x49, 0x73,
+	0x43, 0x72, 0x61, 0x73, 0x68, 0x65, 0x64, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
+	0x17, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x2e, 0x43, 0x72, 0x61, 0x73,
+	0x68, 0x65, 0x64, 0x53, 0x74, 0x61, 0x74, 0x65, 0x22, 0x00, 0x12, 0x4a, 0x0a, 0x10, 0x47, 0x65,
+	0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x1c, 0x2e, 0x73, 0x75, 0x72, 0x66, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x2e, 0x52, 0x61, 0x66, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x22, 0x00, 0x42, 0x1c, 0x5a, 0x1a, 0x63, 0x73, 0x65, 0x32, 0x32, 0x34,
+	0x2f, 0x70, 0x72, 0x6f, 0x6a, 0x34, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x75, 0x72, 0x66, 0x73,
+	0x74, 0x6f, 0x72, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_surfstore_SurfStore_proto_rawDescOnce sync.Once
+	file_pkg_surfstore_SurfStore_proto_rawDescData = file_pkg_surfstore_SurfStore_proto_rawDesc
+)
+
+func file_pkg_surfstore_SurfStore_proto_rawDescGZIP() []byte {
+	file_pkg_surfstore_SurfStore_proto_rawDescOnce.Do(func() {
+		file_pkg_surfstore_SurfStore_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_surfstore_SurfStore_proto_rawDescData)
+	})
+	return file_pkg_surfstore_SurfStore_proto_rawDescData
+}
+
+var file_pkg_surfstore_SurfStore_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_surfstore_SurfStore_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
+var file_pkg_surfstore_SurfStore_proto_goTypes = []interface{}{
+	(Codec)(0),                   // 0: surfstore.Codec
+	(*BlockHash)(nil),            // 1: surfstore.BlockHash
+	(*BlockHashes)(nil),          // 2: surfstore.BlockHashes
+	(*Block)(nil),                // 3: surfstore.Block
+	(*Success)(nil),              // 4: surfstore.Success
+	(*FileMetaData)(nil),         // 5: surfstore.FileMetaData
+	(*FileInfoMap)(nil),          // 6: surfstore.FileInfoMap
+	(*Version)(nil),              // 7: surfstore.Version
+	(*BlockStoreMap)(nil),        // 8: surfstore.BlockStoreMap
+	(*BlockStoreAddrs)(nil),      // 9: surfstore.BlockStoreAddrs
+	(*BlockStoreChange)(nil),     // 10: surfstore.BlockStoreChange
+	(*BlockStoreRing)(nil),       // 11: surfstore.BlockStoreRing
+	(*MetaLogEntry)(nil),         // 12: surfstore.MetaLogEntry
+	(*MetaSnapshot)(nil),         // 13: surfstore.MetaSnapshot
+	(*RaftHardState)(nil),        // 14: surfstore.RaftHardState
+	(*RaftLogAppend)(nil),        // 15: surfstore.RaftLogAppend
+	(*WatchRequest)(nil),         // 16: surfstore.WatchRequest
+	(*ChangesRequest)(nil),       // 17: surfstore.ChangesRequest
+	(*FileChanges)(nil),          // 18: surfstore.FileChanges
+	(*FileChangeEvent)(nil),      // 19: surfstore.FileChangeEvent
+	(*UpdateOperation)(nil),      // 20: surfstore.UpdateOperation
+	(*AppendEntryInput)(nil),     // 21: surfstore.AppendEntryInput
+	(*AppendEntryOutput)(nil),    // 22: surfstore.AppendEntryOutput
+	(*InstallSnapshotInput)(nil), // 23: surfstore.InstallSnapshotInput
+	(*RequestVoteInput)(nil),     // 24: surfstore.RequestVoteInput
+	(*RequestVoteOutput)(nil),    // 25: surfstore.RequestVoteOutput
+	(*ScrubStatus)(nil),          // 26: surfstore.ScrubStatus
+	(*CorruptBlock)(nil),         // 27: surfstore.CorruptBlock
+	(*EncryptionSalt)(nil),       // 28: surfstore.EncryptionSalt
+	(*CrashedState)(nil),         // 29: surfstore.CrashedState
+	(*RaftInternalState)(nil),    // 30: surfstore.RaftInternalState
+	nil,                          // 31: surfstore.FileInfoMap.FileInfoMapEntry
+	nil,                          // 32: surfstore.BlockStoreMap.BlockStoreMapEntry
+	nil,                          // 33: surfstore.BlockStoreRing.WeightsEntry
+	nil,                          // 34: surfstore.MetaSnapshot.FileInfoMapEntry
+	nil,                          // 35: surfstore.MetaSnapshot.FileSeqsEntry
+	(*emptypb.Empty)(nil),        // 36: google.protobuf.Empty
+}
+var file_pkg_surfstore_SurfStore_proto_depIdxs = []int32{
+	0,  // 0: surfstore.Block.codec:type_name -> surfstore.Codec
+	31, // 1: surfstore.FileInfoMap.fileInfoMap:type_name -> surfstore.FileInfoMap.FileInfoMapEntry
+	32, // 2: surfstore.BlockStoreMap.blockStoreMap:type_name -> surfstore.BlockStoreMap.BlockStoreMapEntry
+	33, // 3: surfstore.BlockStoreRing.weights:type_name -> surfstore.BlockStoreRing.WeightsEntry
+	5,  // 4: surfstore.MetaLogEntry.fileMetaData:type_name -> surfstore.FileMetaData
+	11, // 5: surfstore.MetaLogEntry.blockStoreRing:type_name -> surfstore.BlockStoreRing
+	14, // 6: surfstore.MetaLogEntry.raftState:type_name -> surfstore.RaftHardState
+	15, // 7: surfstore.MetaLogEntry.raftLog:type_name -> surfstore.RaftLogAppend
+	34, // 8: surfstore.MetaSnapshot.fileInfoMap:type_name -> surfstore.MetaSnapshot.FileInfoMapEntry
+	11, // 9: surfstore.MetaSnapshot.blockStoreRing:type_name -> surfstore.BlockStoreRing
+	35, // 10: surfstore.MetaSnapshot.fileSeqs:type_name -> surfstore.MetaSnapshot.FileSeqsEntry
+	14, // 11: surfstore.MetaSnapshot.raftState:type_name -> surfstore.RaftHardState
+	20, // 12: surfstore.MetaSnapshot.raftLog:type_name -> surfstore.UpdateOperation
+	20, // 13: surfstore.RaftLogAppend.entries:type_name -> surfstore.UpdateOperation
+	5,  // 14: surfstore.FileChanges.fileMetaData:type_name -> surfstore.FileMetaData
+	5,  // 15: surfstore.UpdateOperation.fileMetaData:type_name -> surfstore.FileMetaData
+	11, // 16: surfstore.UpdateOperation.blockStoreRing:type_name -> surfstore.BlockStoreRing
+	20, // 17: surfstore.AppendEntryInput.entries:type_name -> surfstore.UpdateOperation
+	13, // 18: surfstore.InstallSnapshotInput.snapshot:type_name -> surfstore.MetaSnapshot
+	27, // 19: surfstore.ScrubStatus.corruptBlocks:type_name -> surfstore.CorruptBlock
+	20, // 20: surfstore.RaftInternalState.log:type_name -> surfstore.UpdateOperation
+	6,  // 21: surfstore.RaftInternalState.metaMap:type_name -> surfstore.FileInfoMap
+	5,  // 22: surfstore.FileInfoMap.FileInfoMapEntry.value:type_name -> surfstore.FileMetaData
+	2,  // 23: surfstore.BlockStoreMap.BlockStoreMapEntry.value:type_name -> surfstore.BlockHashes
+	5,  // 24: surfstore.MetaSnapshot.FileInfoMapEntry.value:type_name -> surfstore.FileMetaData
+	1,  // 25: surfstore.BlockStore.GetBlock:input_type -> surfstore.BlockHash
+	3,  // 26: surfstore.BlockStore.PutBlock:input_type -> surfstore.Block
+	2,  // 27: surfstore.BlockStore.HasBlocks:input_type -> surfstore.BlockHashes
+	36, // 28: surfstore.BlockStore.GetBlockHashes:input_type -> google.protobuf.Empty
+	3,  // 29: surfstore.BlockStore.PutBlocks:input_type -> surfstore.Block
+	2,  // 30: surfstore.BlockStore.GetBlocks:input_type -> surfstore.BlockHashes
+	36, // 31: surfstore.BlockStore.GetScrubStatus:input_type -> google.protobuf.Empty
+	2,  // 32: surfstore.BlockStore.DeleteBlocks:input_type -> surfstore.BlockHashes
+	36, // 33: surfstore.MetaStore.GetFileInfoMap:input_type -> google.protobuf.Empty
+	5,  // 34: surfstore.MetaStore.UpdateFile:input_type -> surfstore.FileMetaData
+	2,  // 35: surfstore.MetaStore.GetBlockStoreMap:input_type -> surfstore.BlockHashes
+	36, // 36: surfstore.MetaStore.GetBlockStoreAddrs:input_type -> google.protobuf.Empty
+	10, // 37: surfstore.MetaStore.AddBlockStore:input_type -> surfstore.BlockStoreChange
+	10, // 38: surfstore.MetaStore.RemoveBlockStore:input_type -> surfstore.BlockStoreChange
+	16, // 39: surfstore.MetaStore.WatchFiles:input_type -> surfstore.WatchRequest
+	17, // 40: surfstore.MetaStore.GetChangesSince:input_type -> surfstore.ChangesRequest
+	36, // 41: surfstore.MetaStore.GetEncryptionSalt:input_type -> google.protobuf.Empty
+	28, // 42: surfstore.MetaStore.SetEncryptionSalt:input_type -> surfstore.EncryptionSalt
+	21, // 43: surfstore.RaftSurfstore.AppendEntries:input_type -> surfstore.AppendEntryInput
+	24, // 44: surfstore.RaftSurfstore.RequestVote:input_type -> surfstore.RequestVoteInput
+	23, // 45: surfstore.RaftSurfstore.InstallSnapshot:input_type -> surfstore.InstallSnapshotInput
+	36, // 46: surfstore.RaftSurfstore.SetLeader:input_type -> google.protobuf.Empty
+	36, // 47: surfstore.RaftSurfstore.SendHeartbeat:input_type -> google.protobuf.Empty
+	36, // 48: surfstore.RaftSurfstore.Crash:input_type -> google.protobuf.Empty
+	36, // 49: surfstore.RaftSurfstore.Restore:input_type -> google.protobuf.Empty
+	36, // 50: surfstore.RaftSurfstore.IsCrashed:input_type -> google.protobuf.Empty
+	36, // 51: surfstore.RaftSurfstore.GetInternalState:input_type -> google.protobuf.Empty
+	3,  // 52: surfstore.BlockStore.GetBlock:output_type -> surfstore.Block
+	4,  // 53: surfstore.BlockStore.PutBlock:output_type -> surfstore.Success
+	2,  // 54: surfstore.BlockStore.HasBlocks:output_type -> surfstore.BlockHashes
+	2,  // 55: surfstore.BlockStore.GetBlockHashes:output_type -> surfstore.BlockHashes
+	1,  // 56: surfstore.BlockStore.PutBlocks:output_type -> surfstore.BlockHash
+	3,  // 57: surfstore.BlockStore.GetBlocks:output_type -> surfstore.Block
+	26, // 58: surfstore.BlockStore.GetScrubStatus:output_type -> surfstore.ScrubStatus
+	2,  // 59: surfstore.BlockStore.DeleteBlocks:output_type -> surfstore.BlockHashes
+	6,  // 60: surfstore.MetaStore.GetFileInfoMap:output_type -> surfstore.FileInfoMap
+	7,  // 61: surfstore.MetaStore.UpdateFile:output_type -> surfstore.Version
+	8,  // 62: surfstore.MetaStore.GetBlockStoreMap:output_type -> surfstore.BlockStoreMap
+	9,  // 63: surfstore.MetaStore.GetBlockStoreAddrs:output_type -> surfstore.BlockStoreAddrs
+	4,  // 64: surfstore.MetaStore.AddBlockStore:output_type -> surfstore.Success
+	4,  // 65: surfstore.MetaStore.RemoveBlockStore:output_type -> surfstore.Success
+	19, // 66: surfstore.MetaStore.WatchFiles:output_type -> surfstore.FileChangeEvent
+	18, // 67: surfstore.MetaStore.GetChangesSince:output_type -> surfstore.FileChanges
+	28, // 68: surfstore.MetaStore.GetEncryptionSalt:output_type -> surfstore.EncryptionSalt
+	28, // 69: surfstore.MetaStore.SetEncryptionSalt:output_type -> surfstore.EncryptionSalt
+	22, // 70: surfstore.RaftSurfstore.AppendEntries:output_type -> surfstore.AppendEntryOutput
+	25, // 71: surfstore.RaftSurfstore.RequestVote:output_type -> surfstore.RequestVoteOutput
+	22, // 72: surfstore.RaftSurfstore.InstallSnapshot:output_type -> surfstore.AppendEntryOutput
+	4,  // 73: surfstore.RaftSurfstore.SetLeader:output_type -> surfstore.Success
+	4,  // 74: surfstore.RaftSurfstore.SendHeartbeat:output_type -> surfstore.Success
+	4,  // 75: surfstore.RaftSurfstore.Crash:output_type -> surfstore.Success
+	4,  // 76: surfstore.RaftSurfstore.Restore:output_type -> surfstore.Success
+	29, // 77: surfstore.RaftSurfstore.IsCrashed:output_type -> surfstore.CrashedState
+	30, // 78: surfstore.RaftSurfstore.GetInternalState:output_type -> surfstore.RaftInternalState
+	52, // [52:79] is the sub-list for method output_type
+	25, // [25:52] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
+}
+
+func init() { file_pkg_surfstore_SurfStore_proto_init() }
+func file_pkg_surfstore_SurfStore_proto_init() {
+	if File_pkg_surfstore_SurfStore_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_surfstore_SurfStore_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockHash); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockHashes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Success); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileMetaData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileInfoMap); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Version); i {
 			case 0:
 				return &v.state
@@ -730,19 +2737,272 @@ func file_pkg_surfstore_SurfStore_proto_init() {
 				return nil
 			}
 		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockStoreChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockStoreRing); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetaLogEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetaSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RaftHardState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RaftLogAppend); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChangesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileChanges); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileChangeEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateOperation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppendEntryInput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppendEntryOutput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InstallSnapshotInput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestVoteInput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestVoteOutput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScrubStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CorruptBlock); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EncryptionSalt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CrashedState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_surfstore_SurfStore_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RaftInternalState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_surfstore_SurfStore_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   35,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_pkg_surfstore_SurfStore_proto_goTypes,
 		DependencyIndexes: file_pkg_surfstore_SurfStore_proto_depIdxs,
+		EnumInfos:         file_pkg_surfstore_SurfStore_proto_enumTypes,
 		MessageInfos:      file_pkg_surfstore_SurfStore_proto_msgTypes,
 	}.Build()
 	File_pkg_surfstore_SurfStore_proto = out.File
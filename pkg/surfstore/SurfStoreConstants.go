@@ -1,9 +1,16 @@
 package surfstore
 
+import "time"
+
 const DEFAULT_META_FILENAME string = "index.db"
 
 const TOMBSTONE_HASHVALUE string = "0"
 const EMPTYFILE_HASHVALUE string = "-1"
+const DIRECTORY_HASHVALUE string = "-2" // the hash list of a directory is just this marker
+
+// a file is downloaded to a temp file in this directory next to the index, and renamed over the target once complete.
+// Like the index it is never synced.
+const DOWNLOAD_TMP_DIR string = DEFAULT_META_FILENAME + "-downloads"
 
 const FILENAME_INDEX int = 0
 const VERSION_INDEX int = 1
@@ -11,3 +18,29 @@ const HASH_LIST_INDEX int = 2
 
 const CONFIG_DELIMITER string = ","
 const HASH_DELIMITER string = " "
+
+const META_WAL_FILENAME string = "meta.wal"
+const META_SNAPSHOT_FILENAME string = "meta.snapshot"
+const SNAPSHOT_INTERVAL int = 1000
+
+const DEFAULT_VIRTUAL_NODES int = 100
+
+// the most files GetChangesSince returns at once
+const CHANGES_PAGE_SIZE int = 1000
+
+// an upload whose blocks keep getting placed with a ring that was replaced by the time of UpdateFile gives up after this
+// many attempts, the next sync tries again
+const STALE_RING_ATTEMPTS int = 3
+
+// membership changes wait for the blocks to be migrated
+const MIGRATION_TIMEOUT time.Duration = 10 * time.Minute
+
+// watch mode: how long the base directory has to be quiet before a sync, and how often the server is polled
+const WATCH_DEBOUNCE time.Duration = 500 * time.Millisecond
+const DEFAULT_POLL_INTERVAL time.Duration = 10 * time.Second
+
+// blocks are moved over PutBlocks/GetBlocks streams in batches of about this many bytes
+const BLOCK_BATCH_BYTES int = 16 * 1024 * 1024
+
+// a download keeps at most this many bytes of blocks that arrived before the blocks in front of them
+const EARLY_BLOCK_BYTES int = BLOCK_BATCH_BYTES
@@ -27,6 +27,14 @@ type BlockStoreClient interface {
 	PutBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Success, error)
 	HasBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockHashes, error)
 	GetBlockHashes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockHashes, error)
+	// many blocks over one stream, every stored block is acknowledged with its hash
+	PutBlocks(ctx context.Context, opts ...grpc.CallOption) (BlockStore_PutBlocksClient, error)
+	// the blocks are streamed back in the order of the hashes
+	GetBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (BlockStore_GetBlocksClient, error)
+	// progress of the background scrubber, and the corrupted blocks it found
+	GetScrubStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ScrubStatus, error)
+	// drops blocks this blockstore no longer owns after a membership change, returns the ones it deleted
+	DeleteBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockHashes, error)
 }
 
 type blockStoreClient struct {
@@ -73,6 +81,87 @@ func (c *blockStoreClient) GetBlockHashes(ctx context.Context, in *emptypb.Empty
 	return out, nil
 }
 
+func (c *blockStoreClient) PutBlocks(ctx context.Context, opts ...grpc.CallOption) (BlockStore_PutBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[0], "/surfstore.BlockStore/PutBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStorePutBlocksClient{stream}
+	return x, nil
+}
+
+type BlockStore_PutBlocksClient interface {
+	Send(*Block) error
+	Recv() (*BlockHash, error)
+	grpc.ClientStream
+}
+
+type blockStorePutBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStorePutBlocksClient) Send(m *Block) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *blockStorePutBlocksClient) Recv() (*BlockHash, error) {
+	m := new(BlockHash)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *blockStoreClient) GetBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (BlockStore_GetBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[1], "/surfstore.BlockStore/GetBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStoreGetBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BlockStore_GetBlocksClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type blockStoreGetBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStoreGetBlocksClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *blockStoreClient) GetScrubStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ScrubStatus, error) {
+	out := new(ScrubStatus)
+	err := c.cc.Invoke(ctx, "/surfstore.BlockStore/GetScrubStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) DeleteBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockHashes, error) {
+	out := new(BlockHashes)
+	err := c.cc.Invoke(ctx, "/surfstore.BlockStore/DeleteBlocks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BlockStoreServer is the server API for BlockStore service.
 // All implementations must embed UnimplementedBlockStoreServer
 // for forward compatibility
@@ -81,6 +170,14 @@ type BlockStoreServer interface {
 	PutBlock(context.Context, *Block) (*Success, error)
 	HasBlocks(context.Context, *BlockHashes) (*BlockHashes, error)
 	GetBlockHashes(context.Context, *emptypb.Empty) (*BlockHashes, error)
+	// many blocks over one stream, every stored block is acknowledged with its hash
+	PutBlocks(BlockStore_PutBlocksServer) error
+	// the blocks are streamed back in the order of the hashes
+	GetBlocks(*BlockHashes, BlockStore_GetBlocksServer) error
+	// progress of the background scrubber, and the corrupted blocks it found
+	GetScrubStatus(context.Context, *emptypb.Empty) (*ScrubStatus, error)
+	// drops blocks this blockstore no longer owns after a membership change, returns the ones it deleted
+	DeleteBlocks(context.Context, *BlockHashes) (*BlockHashes, error)
 	mustEmbedUnimplementedBlockStoreServer()
 }
 
@@ -100,6 +197,18 @@ func (UnimplementedBlockStoreServer) HasBlocks(context.Context, *BlockHashes) (*
 func (UnimplementedBlockStoreServer) GetBlockHashes(context.Context, *emptypb.Empty) (*BlockHashes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlockHashes not implemented")
 }
+func (UnimplementedBlockStoreServer) PutBlocks(BlockStore_PutBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutBlocks not implemented")
+}
+func (UnimplementedBlockStoreServer) GetBlocks(*BlockHashes, BlockStore_GetBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetBlocks not implemented")
+}
+func (UnimplementedBlockStoreServer) GetScrubStatus(context.Context, *emptypb.Empty) (*ScrubStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScrubStatus not implemented")
+}
+func (UnimplementedBlockStoreServer) DeleteBlocks(context.Context, *BlockHashes) (*BlockHashes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBlocks not implemented")
+}
 func (UnimplementedBlockStoreServer) mustEmbedUnimplementedBlockStoreServer() {}
 
 // UnsafeBlockStoreServer may be embedded to opt out of forward compatibility for this service.
@@ -185,6 +294,89 @@ func _BlockStore_GetBlockHashes_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BlockStore_PutBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BlockStoreServer).PutBlocks(&blockStorePutBlocksServer{stream})
+}
+
+type BlockStore_PutBlocksServer interface {
+	Send(*BlockHash) error
+	Recv() (*Block, error)
+	grpc.ServerStream
+}
+
+type blockStorePutBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStorePutBlocksServer) Send(m *BlockHash) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *blockStorePutBlocksServer) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BlockStore_GetBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BlockHashes)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BlockStoreServer).GetBlocks(m, &blockStoreGetBlocksServer{stream})
+}
+
+type BlockStore_GetBlocksServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type blockStoreGetBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStoreGetBlocksServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BlockStore_GetScrubStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).GetScrubStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.BlockStore/GetScrubStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).GetScrubStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_DeleteBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHashes)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).DeleteBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.BlockStore/DeleteBlocks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).DeleteBlocks(ctx, req.(*BlockHashes))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BlockStore_ServiceDesc is the grpc.ServiceDesc for BlockStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -208,8 +400,28 @@ var BlockStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlockHashes",
 			Handler:    _BlockStore_GetBlockHashes_Handler,
 		},
+		{
+			MethodName: "GetScrubStatus",
+			Handler:    _BlockStore_GetScrubStatus_Handler,
+		},
+		{
+			MethodName: "DeleteBlocks",
+			Handler:    _BlockStore_DeleteBlocks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PutBlocks",
+			Handler:       _BlockStore_PutBlocks_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetBlocks",
+			Handler:       _BlockStore_GetBlocks_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/surfstore/SurfStore.proto",
 }
 
@@ -221,6 +433,17 @@ type MetaStoreClient interface {
 	UpdateFile(ctx context.Context, in *FileMetaData, opts ...grpc.CallOption) (*Version, error)
 	GetBlockStoreMap(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockStoreMap, error)
 	GetBlockStoreAddrs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockStoreAddrs, error)
+	// admin interface
+	AddBlockStore(ctx context.Context, in *BlockStoreChange, opts ...grpc.CallOption) (*Success, error)
+	RemoveBlockStore(ctx context.Context, in *BlockStoreChange, opts ...grpc.CallOption) (*Success, error)
+	// streams every file change after sinceSeq, then every change as it is committed
+	WatchFiles(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MetaStore_WatchFilesClient, error)
+	// the files changed after sinceSeq, at most limit of them at a time
+	GetChangesSince(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*FileChanges, error)
+	// the salt clients derive the encryption key of a passphrase with, empty if no client created one yet
+	GetEncryptionSalt(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EncryptionSalt, error)
+	// sets the salt unless there already is one, returns the salt in effect (the first one set wins)
+	SetEncryptionSalt(ctx context.Context, in *EncryptionSalt, opts ...grpc.CallOption) (*EncryptionSalt, error)
 }
 
 type metaStoreClient struct {
@@ -267,6 +490,83 @@ func (c *metaStoreClient) GetBlockStoreAddrs(ctx context.Context, in *emptypb.Em
 	return out, nil
 }
 
+func (c *metaStoreClient) AddBlockStore(ctx context.Context, in *BlockStoreChange, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/AddBlockStore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) RemoveBlockStore(ctx context.Context, in *BlockStoreChange, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/RemoveBlockStore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) WatchFiles(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MetaStore_WatchFilesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MetaStore_ServiceDesc.Streams[0], "/surfstore.MetaStore/WatchFiles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &metaStoreWatchFilesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MetaStore_WatchFilesClient interface {
+	Recv() (*FileChangeEvent, error)
+	grpc.ClientStream
+}
+
+type metaStoreWatchFilesClient struct {
+	grpc.ClientStream
+}
+
+func (x *metaStoreWatchFilesClient) Recv() (*FileChangeEvent, error) {
+	m := new(FileChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *metaStoreClient) GetChangesSince(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*FileChanges, error) {
+	out := new(FileChanges)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetChangesSince", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) GetEncryptionSalt(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*EncryptionSalt, error) {
+	out := new(EncryptionSalt)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetEncryptionSalt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) SetEncryptionSalt(ctx context.Context, in *EncryptionSalt, opts ...grpc.CallOption) (*EncryptionSalt, error) {
+	out := new(EncryptionSalt)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/SetEncryptionSalt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetaStoreServer is the server API for MetaStore service.
 // All implementations must embed UnimplementedMetaStoreServer
 // for forward compatibility
@@ -275,6 +575,17 @@ type MetaStoreServer interface {
 	UpdateFile(context.Context, *FileMetaData) (*Version, error)
 	GetBlockStoreMap(context.Context, *BlockHashes) (*BlockStoreMap, error)
 	GetBlockStoreAddrs(context.Context, *emptypb.Empty) (*BlockStoreAddrs, error)
+	// admin interface
+	AddBlockStore(context.Context, *BlockStoreChange) (*Success, error)
+	RemoveBlockStore(context.Context, *BlockStoreChange) (*Success, error)
+	// streams every file change after sinceSeq, then every change as it is committed
+	WatchFiles(*WatchRequest, MetaStore_WatchFilesServer) error
+	// the files changed after sinceSeq, at most limit of them at a time
+	GetChangesSince(context.Context, *ChangesRequest) (*FileChanges, error)
+	// the salt clients derive the encryption key of a passphrase with, empty if no client created one yet
+	GetEncryptionSalt(context.Context, *emptypb.Empty) (*EncryptionSalt, error)
+	// sets the salt unless there already is one, returns the salt in effect (the first one set wins)
+	SetEncryptionSalt(context.Context, *EncryptionSalt) (*EncryptionSalt, error)
 	mustEmbedUnimplementedMetaStoreServer()
 }
 
@@ -294,6 +605,24 @@ func (UnimplementedMetaStoreServer) GetBlockStoreMap(context.Context, *BlockHash
 func (UnimplementedMetaStoreServer) GetBlockStoreAddrs(context.Context, *emptypb.Empty) (*BlockStoreAddrs, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlockStoreAddrs not implemented")
 }
+func (UnimplementedMetaStoreServer) AddBlockStore(context.Context, *BlockStoreChange) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBlockStore not implemented")
+}
+func (UnimplementedMetaStoreServer) RemoveBlockStore(context.Context, *BlockStoreChange) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBlockStore not implemented")
+}
+func (UnimplementedMetaStoreServer) WatchFiles(*WatchRequest, MetaStore_WatchFilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchFiles not implemented")
+}
+func (UnimplementedMetaStoreServer) GetChangesSince(context.Context, *ChangesRequest) (*FileChanges, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChangesSince not implemented")
+}
+func (UnimplementedMetaStoreServer) GetEncryptionSalt(context.Context, *emptypb.Empty) (*EncryptionSalt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEncryptionSalt not implemented")
+}
+func (UnimplementedMetaStoreServer) SetEncryptionSalt(context.Context, *EncryptionSalt) (*EncryptionSalt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetEncryptionSalt not implemented")
+}
 func (UnimplementedMetaStoreServer) mustEmbedUnimplementedMetaStoreServer() {}
 
 // UnsafeMetaStoreServer may be embedded to opt out of forward compatibility for this service.
@@ -379,6 +708,117 @@ func _MetaStore_GetBlockStoreAddrs_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetaStore_AddBlockStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockStoreChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).AddBlockStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/AddBlockStore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).AddBlockStore(ctx, req.(*BlockStoreChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_RemoveBlockStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockStoreChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).RemoveBlockStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/RemoveBlockStore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).RemoveBlockStore(ctx, req.(*BlockStoreChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_WatchFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MetaStoreServer).WatchFiles(m, &metaStoreWatchFilesServer{stream})
+}
+
+type MetaStore_WatchFilesServer interface {
+	Send(*FileChangeEvent) error
+	grpc.ServerStream
+}
+
+type metaStoreWatchFilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *metaStoreWatchFilesServer) Send(m *FileChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MetaStore_GetChangesSince_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetChangesSince(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetChangesSince",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetChangesSince(ctx, req.(*ChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_GetEncryptionSalt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetEncryptionSalt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetEncryptionSalt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetEncryptionSalt(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_SetEncryptionSalt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptionSalt)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).SetEncryptionSalt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/SetEncryptionSalt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).SetEncryptionSalt(ctx, req.(*EncryptionSalt))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetaStore_ServiceDesc is the grpc.ServiceDesc for MetaStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -402,6 +842,410 @@ var MetaStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlockStoreAddrs",
 			Handler:    _MetaStore_GetBlockStoreAddrs_Handler,
 		},
+		{
+			MethodName: "AddBlockStore",
+			Handler:    _MetaStore_AddBlockStore_Handler,
+		},
+		{
+			MethodName: "RemoveBlockStore",
+			Handler:    _MetaStore_RemoveBlockStore_Handler,
+		},
+		{
+			MethodName: "GetChangesSince",
+			Handler:    _MetaStore_GetChangesSince_Handler,
+		},
+		{
+			MethodName: "GetEncryptionSalt",
+			Handler:    _MetaStore_GetEncryptionSalt_Handler,
+		},
+		{
+			MethodName: "SetEncryptionSalt",
+			Handler:    _MetaStore_SetEncryptionSalt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchFiles",
+			Handler:       _MetaStore_WatchFiles_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/surfstore/SurfStore.proto",
+}
+
+// RaftSurfstoreClient is the client API for RaftSurfstore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RaftSurfstoreClient interface {
+	AppendEntries(ctx context.Context, in *AppendEntryInput, opts ...grpc.CallOption) (*AppendEntryOutput, error)
+	RequestVote(ctx context.Context, in *RequestVoteInput, opts ...grpc.CallOption) (*RequestVoteOutput, error)
+	// replaces the follower's state with the leader's snapshot, when it is missing entries the leader compacted
+	InstallSnapshot(ctx context.Context, in *InstallSnapshotInput, opts ...grpc.CallOption) (*AppendEntryOutput, error)
+	// testing interface
+	SetLeader(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error)
+	SendHeartbeat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error)
+	Crash(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error)
+	Restore(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error)
+	IsCrashed(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CrashedState, error)
+	GetInternalState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RaftInternalState, error)
+}
+
+type raftSurfstoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRaftSurfstoreClient(cc grpc.ClientConnInterface) RaftSurfstoreClient {
+	return &raftSurfstoreClient{cc}
+}
+
+func (c *raftSurfstoreClient) AppendEntries(ctx context.Context, in *AppendEntryInput, opts ...grpc.CallOption) (*AppendEntryOutput, error) {
+	out := new(AppendEntryOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/AppendEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) RequestVote(ctx context.Context, in *RequestVoteInput, opts ...grpc.CallOption) (*RequestVoteOutput, error) {
+	out := new(RequestVoteOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/RequestVote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) InstallSnapshot(ctx context.Context, in *InstallSnapshotInput, opts ...grpc.CallOption) (*AppendEntryOutput, error) {
+	out := new(AppendEntryOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/InstallSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) SetLeader(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/SetLeader", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) SendHeartbeat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/SendHeartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) Crash(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/Crash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) Restore(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) IsCrashed(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CrashedState, error) {
+	out := new(CrashedState)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/IsCrashed", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) GetInternalState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RaftInternalState, error) {
+	out := new(RaftInternalState)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/GetInternalState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RaftSurfstoreServer is the server API for RaftSurfstore service.
+// All implementations must embed UnimplementedRaftSurfstoreServer
+// for forward compatibility
+type RaftSurfstoreServer interface {
+	AppendEntries(context.Context, *AppendEntryInput) (*AppendEntryOutput, error)
+	RequestVote(context.Context, *RequestVoteInput) (*RequestVoteOutput, error)
+	// replaces the follower's state with the leader's snapshot, when it is missing entries the leader compacted
+	InstallSnapshot(context.Context, *InstallSnapshotInput) (*AppendEntryOutput, error)
+	// testing interface
+	SetLeader(context.Context, *emptypb.Empty) (*Success, error)
+	SendHeartbeat(context.Context, *emptypb.Empty) (*Success, error)
+	Crash(context.Context, *emptypb.Empty) (*Success, error)
+	Restore(context.Context, *emptypb.Empty) (*Success, error)
+	IsCrashed(context.Context, *emptypb.Empty) (*CrashedState, error)
+	GetInternalState(context.Context, *emptypb.Empty) (*RaftInternalState, error)
+	mustEmbedUnimplementedRaftSurfstoreServer()
+}
+
+// UnimplementedRaftSurfstoreServer must be embedded to have forward compatible implementations.
+type UnimplementedRaftSurfstoreServer struct {
+}
+
+func (UnimplementedRaftSurfstoreServer) AppendEntries(context.Context, *AppendEntryInput) (*AppendEntryOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) RequestVote(context.Context, *RequestVoteInput) (*RequestVoteOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) InstallSnapshot(context.Context, *InstallSnapshotInput) (*AppendEntryOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) SetLeader(context.Context, *emptypb.Empty) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLeader not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) SendHeartbeat(context.Context, *emptypb.Empty) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendHeartbeat not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) Crash(context.Context, *emptypb.Empty) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Crash not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) Restore(context.Context, *emptypb.Empty) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) IsCrashed(context.Context, *emptypb.Empty) (*CrashedState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsCrashed not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) GetInternalState(context.Context, *emptypb.Empty) (*RaftInternalState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInternalState not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) mustEmbedUnimplementedRaftSurfstoreServer() {}
+
+// UnsafeRaftSurfstoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RaftSurfstoreServer will
+// result in compilation errors.
+type UnsafeRaftSurfstoreServer interface {
+	mustEmbedUnimplementedRaftSurfstoreServer()
+}
+
+func RegisterRaftSurfstoreServer(s grpc.ServiceRegistrar, srv RaftSurfstoreServer) {
+	s.RegisterService(&RaftSurfstore_ServiceDesc, srv)
+}
+
+func _RaftSurfstore_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntryInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/AppendEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).AppendEntries(ctx, req.(*AppendEntryInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestVoteInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/RequestVote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).RequestVote(ctx, req.(*RequestVoteInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstallSnapshotInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/InstallSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).InstallSnapshot(ctx, req.(*InstallSnapshotInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_SetLeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).SetLeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/SetLeader",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).SetLeader(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_SendHeartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).SendHeartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/SendHeartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).SendHeartbeat(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_Crash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).Crash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/Crash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).Crash(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).Restore(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_IsCrashed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).IsCrashed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/IsCrashed",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).IsCrashed(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_GetInternalState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).GetInternalState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/GetInternalState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).GetInternalState(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RaftSurfstore_ServiceDesc is the grpc.ServiceDesc for RaftSurfstore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RaftSurfstore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "surfstore.RaftSurfstore",
+	HandlerType: (*RaftSurfstoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AppendEntries",
+			Handler:    _RaftSurfstore_AppendEntries_Handler,
+		},
+		{
+			MethodName: "RequestVote",
+			Handler:    _RaftSurfstore_RequestVote_Handler,
+		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _RaftSurfstore_InstallSnapshot_Handler,
+		},
+		{
+			MethodName: "SetLeader",
+			Handler:    _RaftSurfstore_SetLeader_Handler,
+		},
+		{
+			MethodName: "SendHeartbeat",
+			Handler:    _RaftSurfstore_SendHeartbeat_Handler,
+		},
+		{
+			MethodName: "Crash",
+			Handler:    _RaftSurfstore_Crash_Handler,
+		},
+		{
+			MethodName: "Restore",
+			Handler:    _RaftSurfstore_Restore_Handler,
+		},
+		{
+			MethodName: "IsCrashed",
+			Handler:    _RaftSurfstore_IsCrashed_Handler,
+		},
+		{
+			MethodName: "GetInternalState",
+			Handler:    _RaftSurfstore_GetInternalState_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/surfstore/SurfStore.proto",
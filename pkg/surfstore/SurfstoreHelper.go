@@ -136,6 +136,195 @@ func LoadMetaFromMetaFile(baseDir string) (fileMetaMap map[string]*FileMetaData,
 	return fileMetaMap, nil
 }
 
+/*
+	Remote Index Related
+*/
+
+// the remote index as of the change cursor, so the next sync only asks the metastore for what changed after it
+const createRemoteTable string = `CREATE table IF NOT EXISTS remote_indexes (
+		fileName TEXT,
+		version INT,
+		hashIndex INT,
+		hashValue TEXT
+	);`
+
+const createCursorTable string = `CREATE table IF NOT EXISTS cursor (
+		seq INT,
+		epoch TEXT
+	);`
+
+const insertRemoteTuple string = `INSERT INTO remote_indexes (fileName, version, hashIndex, hashValue) VALUES (?, ?, ?, ?);`
+
+const insertCursor string = `INSERT INTO cursor (seq, epoch) VALUES (?, ?);`
+
+const getRemoteTuples string = `SELECT fileName, version, hashValue FROM remote_indexes order by fileName, hashIndex;`
+
+const getCursor string = `SELECT seq, epoch FROM cursor;`
+
+// the cursor table got the epoch column later, an older one counts as no cursor at all
+const hasCursorTable string = `SELECT count(*) FROM pragma_table_info('cursor') WHERE name = 'epoch';`
+
+// WriteRemoteIndex stores the remote index and the change cursor (seq and the metastore epoch it belongs to)
+// it is up to date with in index.db. WriteMetaFile recreates index.db, so this has to be called after it.
+func WriteRemoteIndex(remoteIndex map[string]*FileMetaData, seq int64, epoch string, baseDir string) error {
+	db, err := sql.Open("sqlite3", ConcatPath(baseDir, DEFAULT_META_FILENAME))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// one transaction, a commit per row is far too slow for a big namespace
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range []string{createRemoteTable, createCursorTable, `DELETE FROM remote_indexes;`, `DELETE FROM cursor;`} {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	insert, err := tx.Prepare(insertRemoteTuple)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, fileMeta := range remoteIndex {
+		for idx, hash := range fileMeta.BlockHashList {
+			if _, err := insert.Exec(fileMeta.Filename, fileMeta.Version, idx, hash); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(insertCursor, seq, epoch); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadRemoteIndexFromMetaFile loads what WriteRemoteIndex stored, an index.db written before change cursors
+// (or epochs) existed, or none at all, gives an empty remote index and seq 0, i.e. a full sync.
+func LoadRemoteIndexFromMetaFile(baseDir string) (map[string]*FileMetaData, int64, string, error) {
+	remoteIndex := make(map[string]*FileMetaData)
+	metaFilePath := ConcatPath(baseDir, DEFAULT_META_FILENAME)
+	if _, err := os.Stat(metaFilePath); err != nil {
+		return remoteIndex, 0, "", nil
+	}
+	db, err := sql.Open("sqlite3", metaFilePath)
+	if err != nil {
+		return remoteIndex, 0, "", err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow(hasCursorTable).Scan(&tables); err != nil || tables == 0 {
+		return remoteIndex, 0, "", err
+	}
+	var seq int64
+	var epoch string
+	if err := db.QueryRow(getCursor).Scan(&seq, &epoch); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		return remoteIndex, 0, "", err
+	}
+
+	tuples, err := db.Query(getRemoteTuples)
+	if err != nil {
+		return make(map[string]*FileMetaData), 0, "", err
+	}
+	defer tuples.Close()
+	for tuples.Next() {
+		var filename, hashValue string
+		var version int32
+		if err := tuples.Scan(&filename, &version, &hashValue); err != nil {
+			return make(map[string]*FileMetaData), 0, "", err
+		}
+		if remoteIndex[filename] == nil {
+			remoteIndex[filename] = &FileMetaData{Filename: filename, Version: version}
+		}
+		remoteIndex[filename].BlockHashList = append(remoteIndex[filename].BlockHashList, hashValue)
+	}
+	return remoteIndex, seq, epoch, tuples.Err()
+}
+
+/*
+	Under-replicated Blocks Related
+*/
+
+// blocks that reached a write quorum but not every replica, see ReplicaRepair.go
+const createUnderReplicatedTable string = `CREATE table IF NOT EXISTS under_replicated (
+		hashValue TEXT,
+		blockStoreAddr TEXT
+	);`
+
+const insertUnderReplicated string = `INSERT INTO under_replicated (hashValue, blockStoreAddr) VALUES (?, ?);`
+
+const getUnderReplicated string = `SELECT hashValue, blockStoreAddr FROM under_replicated;`
+
+const hasUnderReplicatedTable string = `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'under_replicated';`
+
+// WriteUnderReplicated stores the blocks still missing from some of their replicas (hash : replicas) in index.db.
+// WriteMetaFile recreates index.db, so this has to be called after it.
+func WriteUnderReplicated(missing map[string][]string, baseDir string) error {
+	db, err := sql.Open("sqlite3", ConcatPath(baseDir, DEFAULT_META_FILENAME))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range []string{createUnderReplicatedTable, `DELETE FROM under_replicated;`} {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	for hash, blockStoreAddrs := range missing {
+		for _, blockStoreAddr := range blockStoreAddrs {
+			if _, err := tx.Exec(insertUnderReplicated, hash, blockStoreAddr); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadUnderReplicatedFromMetaFile loads what WriteUnderReplicated stored, nothing if index.db has no such table
+func LoadUnderReplicatedFromMetaFile(baseDir string) (map[string][]string, error) {
+	missing := make(map[string][]string)
+	metaFilePath := ConcatPath(baseDir, DEFAULT_META_FILENAME)
+	if _, err := os.Stat(metaFilePath); err != nil {
+		return missing, nil
+	}
+	db, err := sql.Open("sqlite3", metaFilePath)
+	if err != nil {
+		return missing, err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow(hasUnderReplicatedTable).Scan(&tables); err != nil || tables == 0 {
+		return missing, err
+	}
+	rows, err := db.Query(getUnderReplicated)
+	if err != nil {
+		return missing, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash, blockStoreAddr string
+		if err := rows.Scan(&hash, &blockStoreAddr); err != nil {
+			return make(map[string][]string), err
+		}
+		missing[hash] = append(missing[hash], blockStoreAddr)
+	}
+	return missing, rows.Err()
+}
+
 /*
 	Debugging Related
 */
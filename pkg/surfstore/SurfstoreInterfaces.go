@@ -10,6 +10,9 @@ type MetaStoreInterface interface {
 	// Retrieves the server's FileInfoMap
 	GetFileInfoMap(ctx context.Context, _ *emptypb.Empty) (*FileInfoMap, error)
 
+	// Retrieves the files changed after a seq, a page at a time
+	GetChangesSince(ctx context.Context, changesRequest *ChangesRequest) (*FileChanges, error)
+
 	// Update a file's fileinfo entry
 	UpdateFile(ctx context.Context, fileMetaData *FileMetaData) (*Version, error)
 
@@ -18,6 +21,21 @@ type MetaStoreInterface interface {
 
 	// Retrieve all BlockStore Addresses
 	GetBlockStoreAddrs(ctx context.Context, _ *emptypb.Empty) (*BlockStoreAddrs, error)
+
+	// Admin: add a BlockStore to the ring, migrating the blocks it becomes responsible for
+	AddBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error)
+
+	// Admin: remove a BlockStore from the ring, migrating its blocks to their new owners
+	RemoveBlockStore(ctx context.Context, change *BlockStoreChange) (*Success, error)
+
+	// Stream the file changes after a seq, and then every change as it happens
+	WatchFiles(watchRequest *WatchRequest, stream MetaStore_WatchFilesServer) error
+
+	// Retrieve the passphrase salt of the encrypting clients
+	GetEncryptionSalt(ctx context.Context, _ *emptypb.Empty) (*EncryptionSalt, error)
+
+	// Set the passphrase salt if there is none yet, returns the salt in effect
+	SetEncryptionSalt(ctx context.Context, salt *EncryptionSalt) (*EncryptionSalt, error)
 }
 
 type BlockStoreInterface interface {
@@ -34,18 +52,67 @@ type BlockStoreInterface interface {
 
 	// Get which blocks are on this BlockStore server
 	GetBlockHashes(ctx context.Context, _ *emptypb.Empty) (*BlockHashes, error)
+
+	// Put many blocks over one stream, each stored block is acknowledged with its hash
+	PutBlocks(stream BlockStore_PutBlocksServer) error
+
+	// Get many blocks over one stream, in the order of the hashes
+	GetBlocks(blockHashes *BlockHashes, stream BlockStore_GetBlocksServer) error
+
+	// Progress of the background scrubber and the corrupted blocks it found
+	GetScrubStatus(ctx context.Context, _ *emptypb.Empty) (*ScrubStatus, error)
+
+	// Delete the given blocks, returns the subset that was there
+	DeleteBlocks(ctx context.Context, blockHashesIn *BlockHashes) (*BlockHashes, error)
+}
+
+type RaftInterface interface {
+	// Replicate log entries from the leader, also used as the heartbeat
+	AppendEntries(ctx context.Context, input *AppendEntryInput) (*AppendEntryOutput, error)
+
+	// Ask for a vote during leader election
+	RequestVote(ctx context.Context, input *RequestVoteInput) (*RequestVoteOutput, error)
+
+	// Replace the state of a follower that is missing entries the leader already compacted
+	InstallSnapshot(ctx context.Context, input *InstallSnapshotInput) (*AppendEntryOutput, error)
+
+	// Testing interface: force this server to become the leader
+	SetLeader(ctx context.Context, _ *emptypb.Empty) (*Success, error)
+
+	// Testing interface: send one round of heartbeats, succeeds if a majority answered
+	SendHeartbeat(ctx context.Context, _ *emptypb.Empty) (*Success, error)
+
+	// Testing interface: make the server reject every RPC until it is restored
+	Crash(ctx context.Context, _ *emptypb.Empty) (*Success, error)
+	Restore(ctx context.Context, _ *emptypb.Empty) (*Success, error)
+	IsCrashed(ctx context.Context, _ *emptypb.Empty) (*CrashedState, error)
+
+	// Testing interface: dump the server's term, log and file map
+	GetInternalState(ctx context.Context, _ *emptypb.Empty) (*RaftInternalState, error)
 }
 
 type ClientInterface interface {
 	// MetaStore
 	GetFileInfoMap(serverFileInfoMap *map[string]*FileMetaData) error
+	GetChangesSince(sinceSeq int64, epoch *string, fileMetaDatas *[]*FileMetaData, nextSeq *int64, more *bool) error
 	UpdateFile(fileMetaData *FileMetaData, latestVersion *int32) error
 	GetBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string) error
+	GetVersionedBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string, ringVersion *int64) error
 	GetBlockStoreAddrs(blockStoreAddrs *[]string) error
+	AddBlockStore(blockStoreAddr string, weight int, succ *bool) error
+	RemoveBlockStore(blockStoreAddr string, succ *bool) error
+	WatchFiles(ctx context.Context, sinceSeq int64, epoch string, handle func(event *FileChangeEvent) error) error
+	GetEncryptionSalt(salt *[]byte) error
 
 	// BlockStore
 	GetBlock(blockHash string, blockStoreAddr string, block *Block) error
 	PutBlock(block *Block, blockStoreAddr string, succ *bool) error
 	HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error
 	GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error
+	PutBlocks(blocks []*Block, blockStoreAddr string, storedHashes *[]string) error
+	GetBlocks(blockHashes []string, blockStoreAddr string, blocks *[]*Block) error
+	GetScrubStatus(blockStoreAddr string, scrubStatus *ScrubStatus) error
+
+	// Close the connections kept open between calls
+	Close() error
 }
@@ -2,149 +2,392 @@ package surfstore
 
 import (
 	context "context"
+	"crypto/rand"
 	"fmt"
+	"io"
+	"log"
+	"strings"
 	"time"
 
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 type RPCClient struct {
-	MetaStoreAddr string
-	BaseDir       string
-	BlockSize     int
+	MetaStoreAddrs []string // every metastore in the cluster, the client looks for the leader among them
+	BaseDir        string
+	BlockSize      int
+	Chunking       string     // how files are split into blocks, FIXED_CHUNKING or CDC_CHUNKING ("" is fixed)
+	Compression    Codec      // how blocks are compressed before they are uploaded
+	Crypter        *Crypter   // encrypts blocks and filenames end to end, nil if encryption is off
+	Options        RPCOptions // timeouts and retries
+	// how many files and block transfers are in flight at once, in total and against one blockstore (0 is the default)
+	Concurrency          int
+	PerServerConcurrency int
+
+	conns *ConnPool // shared by every copy of the client, see dial
 }
 
+// GetBlock fetches the block with the given hash, a block that doesn't hash to it is a BlockCorruptionError
 func (surfClient *RPCClient) GetBlock(blockHash string, blockStoreAddr string, block *Block) error {
-	// connect to the server
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
-	}
-	c := NewBlockStoreClient(conn)
+	return surfClient.Options.retry(func() error {
+		// connect to the server
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		b, err := c.GetBlock(ctx, &BlockHash{Hash: blockHash})
+		if err != nil {
+			return err
+		}
+		// a corrupted block stays corrupted, it isn't retried
+		if _, err := VerifyBlock(blockHash, b); err != nil {
+			if corruption, ok := err.(*BlockCorruptionError); ok {
+				corruption.Source = blockStoreAddr
+			}
+			return err
+		}
+		block.BlockData = b.BlockData
+		block.BlockSize = b.BlockSize
+		block.Codec = b.Codec
+
+		return nil
+	})
+}
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	b, err := c.GetBlock(ctx, &BlockHash{Hash: blockHash})
-	if err != nil {
-		conn.Close()
-		return err
-	}
-	block.BlockData = b.BlockData
-	block.BlockSize = b.BlockSize
+func (surfClient *RPCClient) PutBlock(block *Block, blockStoreAddr string, succ *bool) error {
+	return surfClient.Options.retry(func() error {
+		// connect to the server
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		s, err := c.PutBlock(ctx, block)
+		if err != nil {
+			return err
+		}
+		*succ = s.Flag // deep copy: 解析这个pointer指向的位置，将其赋值给succ，然后将succ赋值给*succ，最后将*succ赋值给succ
+
+		return nil
+	})
+}
 
-	// close the connection
-	return conn.Close()
+func (surfClient *RPCClient) HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error {
+	return surfClient.Options.retry(func() error {
+		// connect to the server
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		b, err := c.HasBlocks(ctx, &BlockHashes{Hashes: blockHashesIn}) // 取地址符
+		if err != nil {
+			return err
+		}
+		*blockHashesOut = b.Hashes
+
+		return nil
+	})
 }
 
-func (surfClient *RPCClient) PutBlock(block *Block, blockStoreAddr string, succ *bool) error {
-	// connect to the server
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
-	}
-	c := NewBlockStoreClient(conn)
+func (surfClient *RPCClient) GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error {
+	return surfClient.Options.retry(func() error {
+		// connect to the server
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		g, err := c.GetBlockHashes(ctx, &emptypb.Empty{}) // 取地址符
+		if err != nil {
+			return err
+		}
+		*blockHashes = g.Hashes
+
+		return nil
+	})
+}
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	s, err := c.PutBlock(ctx, block)
-	if err != nil {
-		conn.Close()
-		return err
-	}
-	*succ = s.Flag // deep copy: 解析这个pointer指向的位置，将其赋值给succ，然后将succ赋值给*succ，最后将*succ赋值给succ
+// DeleteBlocks removes the blocks from the blockstore, deletedHashes gets the ones it had
+func (surfClient *RPCClient) DeleteBlocks(blockHashesIn []string, blockStoreAddr string, deletedHashes *[]string) error {
+	return surfClient.Options.retry(func() error {
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		d, err := c.DeleteBlocks(ctx, &BlockHashes{Hashes: blockHashesIn})
+		if err != nil {
+			return err
+		}
+		*deletedHashes = d.Hashes
+
+		return nil
+	})
+}
 
-	// close the connection
-	return conn.Close()
+// GetScrubStatus fills scrubStatus with the progress of the blockstore's scrubber
+func (surfClient *RPCClient) GetScrubStatus(blockStoreAddr string, scrubStatus *ScrubStatus) error {
+	return surfClient.Options.retry(func() error {
+		// connect to the server
+		conn, release, err := surfClient.dial(blockStoreAddr)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c := NewBlockStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.blockTimeout())
+		defer cancel()
+		st, err := c.GetScrubStatus(ctx, &emptypb.Empty{})
+		if err != nil {
+			return err
+		}
+		// a message can't be copied by value
+		proto.Reset(scrubStatus)
+		proto.Merge(scrubStatus, st)
+
+		return nil
+	})
 }
 
-func (surfClient *RPCClient) HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error {
+// PutBlocks sends all blocks to the blockstore over one stream, and sets storedHashes to the hashes of the blocks
+// it acknowledged. The blocks are sent while the acknowledgements come back, gRPC's flow control keeps the sender
+// from running ahead of the blockstore. On an error storedHashes still holds the blocks that were stored.
+// If the stream breaks, the blocks that were not acknowledged yet are sent again over a new one.
+func (surfClient *RPCClient) PutBlocks(blocks []*Block, blockStoreAddr string, storedHashes *[]string) error {
+	*storedHashes = []string{}
+	return surfClient.Options.retry(func() error {
+		var stored []string
+		err := surfClient.putBlocks(blocks, blockStoreAddr, &stored)
+		// the blockstore stores and acknowledges the blocks in order
+		*storedHashes = append(*storedHashes, stored...)
+		blocks = blocks[len(stored):]
+		return err
+	})
+}
+
+func (surfClient *RPCClient) putBlocks(blocks []*Block, blockStoreAddr string, storedHashes *[]string) error {
+	*storedHashes = []string{}
+
 	// connect to the server
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
+	conn, release, err := surfClient.dial(blockStoreAddr)
 	if err != nil {
 		return err
 	}
+	defer release()
 	c := NewBlockStoreClient(conn)
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.streamTimeout())
 	defer cancel()
-	b, err := c.HasBlocks(ctx, &BlockHashes{Hashes: blockHashesIn}) // 取地址符
+	stream, err := c.PutBlocks(ctx)
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	*blockHashesOut = b.Hashes
 
-	// close the connection
-	return conn.Close()
-}
-
-func (surfClient *RPCClient) GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error {
-	// connect to the server
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, block := range blocks {
+			if err := stream.Send(block); err != nil {
+				// the real error comes out of Recv
+				sendErr <- nil
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	for {
+		h, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cancel() // unblocks the sender
+			<-sendErr
+			return err
+		}
+		*storedHashes = append(*storedHashes, h.Hash)
 	}
-	c := NewBlockStoreClient(conn)
-
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	g, err := c.GetBlockHashes(ctx, &emptypb.Empty{}) // 取地址符
-	if err != nil {
-		conn.Close()
+	if err := <-sendErr; err != nil {
 		return err
 	}
-	*blockHashes = g.Hashes
+	if len(*storedHashes) != len(blocks) {
+		return fmt.Errorf("blockstore %s acknowledged %d of %d blocks", blockStoreAddr, len(*storedHashes), len(blocks))
+	}
+	return nil
+}
 
-	// close the connection
-	return conn.Close()
+// GetBlocks fetches the blocks with the given hashes over one stream, in the same order.
+// On an error blocks still holds the blocks received before it, i.e. those of the first len(*blocks) hashes.
+// Unlike GetBlock it doesn't check the blocks against their hashes, the caller decompresses them anyway and does it then.
+// If the stream breaks, the blocks that were not received yet are asked for again over a new one.
+func (surfClient *RPCClient) GetBlocks(blockHashes []string, blockStoreAddr string, blocks *[]*Block) error {
+	*blocks = []*Block{}
+	return surfClient.Options.retry(func() error {
+		var received []*Block
+		err := surfClient.getBlocks(blockHashes, blockStoreAddr, &received)
+		*blocks = append(*blocks, received...)
+		blockHashes = blockHashes[len(received):]
+		return err
+	})
 }
 
-func (surfClient *RPCClient) GetFileInfoMap(serverFileInfoMap *map[string]*FileMetaData) error {
+func (surfClient *RPCClient) getBlocks(blockHashes []string, blockStoreAddr string, blocks *[]*Block) error {
+	*blocks = []*Block{}
+
 	// connect to the server
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
+	conn, release, err := surfClient.dial(blockStoreAddr)
 	if err != nil {
 		return err
 	}
-	c := NewMetaStoreClient(conn)
+	defer release()
+	c := NewBlockStoreClient(conn)
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), surfClient.Options.streamTimeout())
 	defer cancel()
-	g, err := c.GetFileInfoMap(ctx, &emptypb.Empty{}) // 取地址符
+	stream, err := c.GetBlocks(ctx, &BlockHashes{Hashes: blockHashes})
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	*serverFileInfoMap = g.FileInfoMap
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		*blocks = append(*blocks, b)
+	}
+	if len(*blocks) != len(blockHashes) {
+		return fmt.Errorf("blockstore %s returned %d of %d blocks", blockStoreAddr, len(*blocks), len(blockHashes))
+	}
+	return nil
+}
+
+func (surfClient *RPCClient) GetFileInfoMap(serverFileInfoMap *map[string]*FileMetaData) error {
+	return surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+		g, err := c.GetFileInfoMap(ctx, &emptypb.Empty{}) // 取地址符
+		if err != nil {
+			return err
+		}
+		if surfClient.Crypter == nil {
+			*serverFileInfoMap = g.FileInfoMap
+			return nil
+		}
+		fileInfoMap := make(map[string]*FileMetaData)
+		for _, fileMetaData := range g.FileInfoMap {
+			if decrypted, ok := surfClient.decryptMetaData(fileMetaData); ok {
+				fileInfoMap[decrypted.Filename] = decrypted
+			}
+		}
+		*serverFileInfoMap = fileInfoMap
+		return nil
+	})
+}
 
-	// close the connection
-	return conn.Close()
+// GetChangesSince returns one page of the files changed after sinceSeq, call it again with nextSeq while more is true.
+// epoch is the metastore epoch sinceSeq belongs to ("" if we have none yet), it is set to the epoch of nextSeq.
+func (surfClient *RPCClient) GetChangesSince(sinceSeq int64, epoch *string, fileMetaDatas *[]*FileMetaData, nextSeq *int64, more *bool) error {
+	return surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+		f, err := c.GetChangesSince(ctx, &ChangesRequest{SinceSeq: sinceSeq, Limit: int32(CHANGES_PAGE_SIZE), Epoch: *epoch})
+		if err != nil {
+			return err
+		}
+		*fileMetaDatas = []*FileMetaData{}
+		for _, fileMetaData := range f.FileMetaData {
+			if surfClient.Crypter == nil {
+				*fileMetaDatas = append(*fileMetaDatas, fileMetaData)
+			} else if decrypted, ok := surfClient.decryptMetaData(fileMetaData); ok {
+				*fileMetaDatas = append(*fileMetaDatas, decrypted)
+			}
+		}
+		*nextSeq = f.Seq
+		*epoch = f.Epoch
+		*more = f.More
+		return nil
+	})
 }
 
 func (surfClient *RPCClient) UpdateFile(fileMetaData *FileMetaData, latestVersion *int32) error {
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
-	}
-	c := NewMetaStoreClient(conn)
+	return surfClient.callMetaStore(func(c MetaStoreClient, ctx context.Context) error {
+		v, err := c.UpdateFile(ctx, surfClient.encryptMetaData(fileMetaData))
+		if err != nil {
+			return err
+		}
+		*latestVersion = v.Version
+		return nil
+	})
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	fmt.Println("start of the updateFile")
-	v, err := c.UpdateFile(ctx, fileMetaData)
-	fmt.Println("end of the updateFile")
+// GetEncryptionSalt returns the passphrase salt of the metastore, the first client to ask creates it
+func (surfClient *RPCClient) GetEncryptionSalt(salt *[]byte) error {
+	var current []byte
+	err := surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+		e, err := c.GetEncryptionSalt(ctx, &emptypb.Empty{})
+		if err != nil {
+			return err
+		}
+		current = e.Salt
+		return nil
+	})
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	*latestVersion = v.Version
-
-	return conn.Close()
+	if len(current) == 0 {
+		newSalt := make([]byte, PASSPHRASE_SALT_SIZE)
+		if _, err := rand.Read(newSalt); err != nil {
+			return err
+		}
+		// if another client created it first, the metastore hands us theirs
+		err = surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+			e, err := c.SetEncryptionSalt(ctx, &EncryptionSalt{Salt: newSalt})
+			if err != nil {
+				return err
+			}
+			current = e.Salt
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(current) != PASSPHRASE_SALT_SIZE {
+		return fmt.Errorf("malformed encryption salt in the metastore")
+	}
+	*salt = current
+	return nil
 }
 
 // func (surfClient *RPCClient) GetBlockStoreAddr(blockStoreAddr *string) error {
@@ -170,64 +413,210 @@ func (surfClient *RPCClient) UpdateFile(fileMetaData *FileMetaData, latestVersio
 // }
 
 func (surfClient *RPCClient) GetBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string) error { // 传一个空的进去，return一个满的回来
-	// connect to the server
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
+	var ringVersion int64
+	return surfClient.GetVersionedBlockStoreMap(blockHashesIn, blockStoreMap, &ringVersion)
+}
+
+// GetVersionedBlockStoreMap is GetBlockStoreMap that also returns the version of the ring the blocks were placed with,
+// an upload passes it to UpdateFile (FileMetaData.RingVersion)
+func (surfClient *RPCClient) GetVersionedBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string, ringVersion *int64) error {
+	return surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+		b, err := c.GetBlockStoreMap(ctx, &BlockHashes{Hashes: blockHashesIn}) // 类型不匹配，传的是[]string, 需要的是BlockHashes这个structure
+		if err != nil {
+			return err
+		}
+
+		m := make(map[string][]string) // aim to convert it into the same type to return
+		for blockAdrr, blockHashes := range b.BlockStoreMap {
+			m[blockAdrr] = blockHashes.Hashes
+		}
+		*blockStoreMap = m
+		*ringVersion = b.RingVersion
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) GetBlockStoreAddrs(blockStoreAddrs *[]string) error {
+	return surfClient.callMetaStoreWithRetry(func(c MetaStoreClient, ctx context.Context) error {
+		a, err := c.GetBlockStoreAddrs(ctx, &emptypb.Empty{})
+		if err != nil {
+			return err
+		}
+		*blockStoreAddrs = a.BlockStoreAddrs
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) AddBlockStore(blockStoreAddr string, weight int, succ *bool) error {
+	return surfClient.callMetaStoreWithTimeout(MIGRATION_TIMEOUT, func(c MetaStoreClient, ctx context.Context) error {
+		s, err := c.AddBlockStore(ctx, &BlockStoreChange{Addr: blockStoreAddr, Weight: int32(weight)})
+		if err != nil {
+			return err
+		}
+		*succ = s.Flag
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) RemoveBlockStore(blockStoreAddr string, succ *bool) error {
+	return surfClient.callMetaStoreWithTimeout(MIGRATION_TIMEOUT, func(c MetaStoreClient, ctx context.Context) error {
+		s, err := c.RemoveBlockStore(ctx, &BlockStoreChange{Addr: blockStoreAddr})
+		if err != nil {
+			return err
+		}
+		*succ = s.Flag
+		return nil
+	})
+}
+
+// WatchFiles streams the changes after sinceSeq to handle until ctx is cancelled, the stream breaks or handle returns an error.
+// To resume after an error, call it again with the Seq and Epoch of the last event handled.
+func (surfClient *RPCClient) WatchFiles(ctx context.Context, sinceSeq int64, epoch string, handle func(event *FileChangeEvent) error) error {
+	var lastErr error = fmt.Errorf("no metastore configured")
+	for _, addr := range surfClient.MetaStoreAddrs {
+		// connect to the server
+		conn, release, err := surfClient.dial(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c := NewMetaStoreClient(conn)
+
+		received, err := watchFiles(ctx, c, sinceSeq, epoch, func(event *FileChangeEvent) error {
+			if surfClient.Crypter != nil {
+				filename, err := surfClient.Crypter.DecryptFilename(event.Filename)
+				if err != nil {
+					filename = "" // not ours, but the seq still moves the cursor
+				}
+				event = &FileChangeEvent{Seq: event.Seq, Filename: filename, Version: event.Version, Deleted: event.Deleted, Epoch: event.Epoch}
+			}
+			return handle(event)
+		})
+
+		release()
+		lastErr = err
+		// only move on to the next metastore if this one refused the watch, not if it broke off later
+		if code := status.Code(err); received || (code != codes.Unavailable && code != codes.FailedPrecondition) {
+			return err
+		}
 	}
-	c := NewMetaStoreClient(conn)
+	return lastErr
+}
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	b, err := c.GetBlockStoreMap(ctx, &BlockHashes{Hashes: blockHashesIn}) // 类型不匹配，传的是[]string, 需要的是BlockHashes这个structure
+func watchFiles(ctx context.Context, c MetaStoreClient, sinceSeq int64, epoch string, handle func(event *FileChangeEvent) error) (bool, error) {
+	stream, err := c.WatchFiles(ctx, &WatchRequest{SinceSeq: sinceSeq, Epoch: epoch})
 	if err != nil {
-		conn.Close()
-		return err
+		return false, err
 	}
-
-	m := make(map[string][]string) // aim to convert it into the same type to return
-	for blockAdrr, blockHashes := range b.BlockStoreMap {
-		m[blockAdrr] = blockHashes.Hashes
+	received := false
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if err := handle(event); err != nil {
+			return received, err
+		}
 	}
-	*blockStoreMap = m
+}
 
-	// close the connection
-	return conn.Close()
+// the metastore only ever sees encrypted filenames, the hash lists are hashes of encrypted blocks already
+func (surfClient *RPCClient) encryptMetaData(fileMetaData *FileMetaData) *FileMetaData {
+	if surfClient.Crypter == nil {
+		return fileMetaData
+	}
+	return &FileMetaData{
+		Filename:      surfClient.Crypter.EncryptFilename(fileMetaData.Filename),
+		Version:       fileMetaData.Version,
+		BlockHashList: fileMetaData.BlockHashList,
+		RingVersion:   fileMetaData.RingVersion,
+	}
 }
 
-func (surfClient *RPCClient) GetBlockStoreAddrs(blockStoreAddrs *[]string) error {
-	// connect to the server
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
+// files we can't decrypt were uploaded without encryption or with another key, we leave them alone
+func (surfClient *RPCClient) decryptMetaData(fileMetaData *FileMetaData) (*FileMetaData, bool) {
+	filename, err := surfClient.Crypter.DecryptFilename(fileMetaData.Filename)
 	if err != nil {
-		return err
+		log.Println("Skipping file: ", err)
+		return nil, false
+	}
+	return &FileMetaData{Filename: filename, Version: fileMetaData.Version, BlockHashList: fileMetaData.BlockHashList}, true
+}
+
+// callMetaStore performs the call against the metastores one by one until one of them answers as the leader.
+// A metastore that is down, crashed or not the leader is skipped, any other error is returned right away.
+func (surfClient *RPCClient) callMetaStore(call func(c MetaStoreClient, ctx context.Context) error) error {
+	return surfClient.callMetaStoreWithTimeout(surfClient.Options.metaTimeout(), call)
+}
+
+// callMetaStoreWithRetry is callMetaStore for idempotent calls, they are retried if no metastore could answer
+func (surfClient *RPCClient) callMetaStoreWithRetry(call func(c MetaStoreClient, ctx context.Context) error) error {
+	return surfClient.Options.retry(func() error {
+		return surfClient.callMetaStore(call)
+	})
+}
+
+func (surfClient *RPCClient) callMetaStoreWithTimeout(timeout time.Duration, call func(c MetaStoreClient, ctx context.Context) error) error {
+	var lastErr error = fmt.Errorf("no metastore configured")
+	for _, addr := range surfClient.MetaStoreAddrs {
+		// connect to the server
+		conn, release, err := surfClient.dial(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c := NewMetaStoreClient(conn)
+
+		// perform the call
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = call(c, ctx)
+		cancel()
+		release()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if code := status.Code(err); code != codes.Unavailable && code != codes.FailedPrecondition {
+			return err
+		}
 	}
-	c := NewMetaStoreClient(conn)
+	return lastErr
+}
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	a, err := c.GetBlockStoreAddrs(ctx, &emptypb.Empty{})
+// dial returns a connection to addr and the function to call when done with it. Connections from the pool stay
+// open for the next call, a client without a pool (e.g. RPCClient{}) dials a connection per call and closes it again.
+func (surfClient *RPCClient) dial(addr string) (*grpc.ClientConn, func(), error) {
+	if surfClient.conns != nil {
+		conn, err := surfClient.conns.Get(addr)
+		return conn, func() {}, err
+	}
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
 	if err != nil {
-		conn.Close()
-		return err
+		return nil, nil, err
 	}
-	addr := a.BlockStoreAddrs
-	*blockStoreAddrs = addr
+	return conn, func() { conn.Close() }, nil
+}
 
-	// close the connection
-	return conn.Close()
+// Close closes the pooled connections, the client (and every copy of it) can't be used afterwards
+func (surfClient *RPCClient) Close() error {
+	if surfClient.conns == nil {
+		return nil
+	}
+	return surfClient.conns.Close()
 }
 
 // This line guarantees all method for RPCClient are implemented
 var _ ClientInterface = new(RPCClient)
 
-// Create an Surfstore RPC client
+// Create an Surfstore RPC client, hostPort can be a comma separated list of the metastores in a raft cluster
 func NewSurfstoreRPCClient(hostPort, baseDir string, blockSize int) RPCClient {
 
 	return RPCClient{
-		MetaStoreAddr: hostPort,
-		BaseDir:       baseDir,
-		BlockSize:     blockSize,
+		MetaStoreAddrs: strings.Split(hostPort, CONFIG_DELIMITER),
+		BaseDir:        baseDir,
+		BlockSize:      blockSize,
+		Options:        DefaultRPCOptions(),
+		conns:          NewConnPool(),
 	}
 }
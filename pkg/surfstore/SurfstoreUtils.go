@@ -2,11 +2,20 @@ package surfstore
 
 import (
 	"fmt"
-	"io/ioutil"
-	"math"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	reflect "reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 )
 
 // Implement the logic for a client syncing with the server here.
@@ -14,54 +23,45 @@ import (
 // 要check本地有没有修改 有可能本地修改了 但是还是localindex还是上次和server端sync的index
 // 如果本地修改了 先更新本地的localindex 再sync更新server端的index
 func ClientSync(client RPCClient) {
-	// 1.The client should first scan the base directory, and for each file, compute that file’s hash list.
-	files, err := ioutil.ReadDir(client.BaseDir) // read file error
-	if err != nil {
-		fmt.Println("Error when reading basedir: %v", err)
+	clientSync(client, nil)
+}
+
+// clientSync rescans only changedPaths (relative to the base directory) and trusts the local index for everything else,
+// nil rescans the whole base directory. The remote index is always compared in full.
+func clientSync(client RPCClient, changedPaths []string) {
+	// temp files of downloads a crash interrupted, no download is running between two syncs
+	if err := os.RemoveAll(ConcatPath(client.BaseDir, DOWNLOAD_TMP_DIR)); err != nil {
+		fmt.Println("Could not remove temp files: ", err)
 	}
+
 	localIndex, err := LoadMetaFromMetaFile(client.BaseDir) // the local index we need to update according to files
 	if err != nil {
-		fmt.Println("Could not load meta from meta file: %v", err)
+		fmt.Println("Could not load meta from meta file: ", err)
 	}
 
-	// 2.then consult the local index file and compare the results, to see whether (1) there are now new files in the base directory that aren’t in the index file,
-	// or (2) files that are in the index file, but have changed since the last time the client was executed (i.e., the hash list is different).
-	hashMap := make(map[string][]string)
-	for _, file := range files {
-		// check filename
-		if file.Name() == "index.db" || strings.Contains(file.Name(), ",") || strings.Contains(file.Name(), "/") {
-			continue
-		}
-
-		// get blocks numbers
-		var numsBlocks int = int(math.Ceil(float64(file.Size()) / float64(client.BlockSize)))
-		fileToRead, err := os.Open(client.BaseDir + "/" + file.Name())
-		if err != nil {
-			fmt.Println("Error reading file in basedir: ", err)
-		}
-		defer fileToRead.Close()
-		var hashlist []string
-		byteSlice := make([]byte, client.BlockSize)
-		for i := 0; i < numsBlocks; i++ {
-			// byteSlice := make([]byte, client.BlockSize) // build a byteSlice to contain []bytes in the block
-			len, err := fileToRead.Read(byteSlice) // the lenth of each block, the last block may be less than client.BlockSize
-			if err != nil {
-				fmt.Println("Error reading bytes from file in basedir: ", err)
-			}
-			// byteSlice = byteSlice[:len]           // read []byte from each block
-			hash := GetBlockHashString(byteSlice[:len]) // compute each block's hash, and make intergration, which is the file's hash
-			hashlist = append(hashlist, hash)           // arrcoding to the same file name to append the hash
-		}
-		hashMap[file.Name()] = hashlist
+	// 1.The client should first scan the base directory, and for each file, compute that file’s hash list.
+	var hashMap map[string][]string // relative path : hash list, for every file and directory under the base directory
+	if changedPaths == nil {
+		hashMap, err = scanBaseDir(client)
+	} else {
+		hashMap, err = scanChangedPaths(client, localIndex, changedPaths)
+	}
+	if err != nil {
+		fmt.Println("Error when reading basedir: ", err)
 	}
 
+	// 2.then consult the local index file and compare the results, to see whether (1) there are now new files in the base directory that aren’t in the index file,
+	// or (2) files that are in the index file, but have changed since the last time the client was executed (i.e., the hash list is different).
 	// check in the base directory，local side file VS local database ==> sync
+	modified := make(map[string]bool) // files changed locally since the last sync, these are the ones that can conflict
 	for fileName, hashList := range hashMap {
 		if localIndex[fileName] == nil { // check new file, then update it
 			localIndex[fileName] = &FileMetaData{Filename: fileName, Version: int32(1), BlockHashList: hashList}
+			modified[fileName] = true
 		} else if !reflect.DeepEqual(localIndex[fileName].BlockHashList, hashList) { // check changed file
 			localIndex[fileName].BlockHashList = hashList
 			localIndex[fileName].Version = localIndex[fileName].Version + 1
+			modified[fileName] = true
 			// fmt.Println("file is changed")
 		}
 		// if reflect.DeepEqual(localIndex[fileName].BlockHashList, []string{"0"}) { // check deleted file
@@ -73,9 +73,10 @@ func ClientSync(client RPCClient) {
 	// check in the deleted files: file name in the localIndex and but not in the base directory
 	for fileName, fileMetaData := range localIndex {
 		if _, ok := hashMap[fileName]; !ok { // update the feature of the deleted file
-			if len(fileMetaData.BlockHashList) != 1 || fileMetaData.BlockHashList[0] != "0" {
+			if !isTombstone(fileMetaData.BlockHashList) {
 				fileMetaData.Version++
-				fileMetaData.BlockHashList = []string{"0"}
+				fileMetaData.BlockHashList = []string{TOMBSTONE_HASHVALUE}
+				modified[fileName] = true
 			}
 		}
 	}
@@ -86,8 +87,12 @@ func ClientSync(client RPCClient) {
 		fmt.Println("Could not get blockStoreAddr: ", err)
 	}
 
-	remoteIndex := make(map[string]*FileMetaData)
-	if err := client.GetFileInfoMap(&remoteIndex); err != nil {
+	// only the files changed since the last sync are downloaded, on top of the remote index kept in index.db
+	remoteIndex, cursor, epoch, err := LoadRemoteIndexFromMetaFile(client.BaseDir)
+	if err != nil {
+		fmt.Println("Could not load remote index from meta file: ", err)
+	}
+	if err := fetchRemoteChanges(client, remoteIndex, &cursor, &epoch); err != nil {
 		fmt.Println("Could not get remote index: ", err)
 	}
 	//fmt.Println(remoteIndex)
@@ -99,35 +104,119 @@ func ClientSync(client RPCClient) {
 	// 4.2 there are new files in the local base directory that aren’t in the local index or in the remote index.
 	// The client should upload the blocks corresponding to this file to the server,
 	// then update the server with the new FileInfo.
+	// 4.3 a file changed locally that someone else updated first (the remote version already caught up with ours,
+	// or the server rejected our version with -1) is a conflict, the local content is kept as a conflicted copy
+	// 4.4 files are uploaded and downloaded in parallel, up to client.Concurrency at once, and their blocks are moved
+	// through the transfer pool. Every goroutine only touches the metadata of its own file.
+	conflicts := []string{}
+	stats := &uploadStats{}
+	// blocks that earlier syncs could not get onto all of their replicas are repaired along with the new ones
+	underReplicatedBlocks, err := LoadUnderReplicatedFromMetaFile(client.BaseDir)
+	if err != nil {
+		fmt.Println("Could not load under-replicated blocks from meta file: ", err)
+	}
+	missing := newUnderReplicated(underReplicatedBlocks)
+	pool := NewTransferPool(client.Concurrency, client.PerServerConcurrency)
+	fileSlots := make(chan struct{}, pool.Concurrency)
+	// files whose upload failed keep their local content and version, the server's version of them is not
+	// downloaded over the user's changes, the next sync uploads them again
+	failed := make(map[string]bool)
+	var mu sync.Mutex // guards conflicts and failed
+	var wg sync.WaitGroup
 	for fileName, localMetaData := range localIndex { // check local side, 有几个block就会有几个filename
-		m := make(map[string][]string)
-		err = client.GetBlockStoreMap(hashMap[fileName], &m) //return了一个blockStoreAdrr的map
-		if err != nil {
-			fmt.Println("Could not get blockStoreAddr: ", err)
-		}
-		if remoteMetaData, ok := remoteIndex[fileName]; ok {
-			if localMetaData.Version > remoteMetaData.Version {
-				uploadFile(client, localMetaData, blockStoreAddrs, m)
+		fileSlots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-fileSlots }()
+
+			if remoteMetaData, ok := remoteIndex[fileName]; ok {
+				if localMetaData.Version > remoteMetaData.Version {
+					if err := uploadFile(client, localMetaData, blockStoreAddrs, pool, stats, missing); err != nil {
+						printUploadError(err)
+						mu.Lock()
+						failed[fileName] = true
+						mu.Unlock()
+						return
+					}
+				} else if modified[fileName] && !reflect.DeepEqual(localMetaData.BlockHashList, remoteMetaData.BlockHashList) {
+					mu.Lock()
+					conflicts = append(conflicts, fileName)
+					mu.Unlock()
+					return
+				}
+			} else {
+				if err := uploadFile(client, localMetaData, blockStoreAddrs, pool, stats, missing); err != nil {
+					printUploadError(err)
+					mu.Lock()
+					failed[fileName] = true
+					mu.Unlock()
+					return
+				}
 			}
-		} else {
-			uploadFile(client, localMetaData, blockStoreAddrs, m)
+			if localMetaData.Version == -1 { // UpdateFile lost the race
+				mu.Lock()
+				conflicts = append(conflicts, fileName)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		for _, fileName := range conflicts {
+			saveConflictedCopy(client, localIndex, fileName, blockStoreAddrs, pool, stats, missing)
+		}
+		// the versions that won are not in the remote index we downloaded, get them too
+		if err := fetchRemoteChanges(client, remoteIndex, &cursor, &epoch); err != nil {
+			fmt.Println("Could not get remote index: ", err)
 		}
 	}
-	for fileName, remoteMetaData := range remoteIndex { // check server side
-		m := make(map[string][]string)
-		err = client.GetBlockStoreMap(hashMap[fileName], &m) //return了一个blockStoreAdrr的map
-		if err != nil {
-			fmt.Println("Could not get blockStoreAddr: ", err)
+	// deletions go first and deepest first, so a directory is empty by the time it is removed,
+	// then everything else shallowest first, so a directory exists before the files inside it are written.
+	// deletions are done one by one, the files of one depth are downloaded in parallel once the depth above is done
+	lastDepth := -1
+	for _, fileName := range downloadOrder(remoteIndex) { // check server side
+		if failed[fileName] {
+			continue
 		}
-		if localMetaData, ok := localIndex[fileName]; !ok { // remote index refers to a file not present in the local index
-			localIndex[fileName] = &FileMetaData{}
-			downloadFile(client, localIndex[fileName], remoteMetaData, m) // download the remotefile and update
-		} else {
-			if remoteMetaData.Version >= localIndex[fileName].Version { // only if in this situation we need to update remote side to local side
-				downloadFile(client, localMetaData, remoteMetaData, m)
+		remoteMetaData := remoteIndex[fileName]
+		localMetaData, ok := localIndex[fileName]
+		if !ok { // remote index refers to a file not present in the local index
+			localMetaData = &FileMetaData{}
+			localIndex[fileName] = localMetaData
+		} else if !(remoteMetaData.Version > localMetaData.Version ||
+			(remoteMetaData.Version == localMetaData.Version && !reflect.DeepEqual(remoteMetaData.BlockHashList, localMetaData.BlockHashList))) {
+			// only if in this situation we need to update remote side to local side, an equal version with the same blocks is already up to date
+			continue
+		}
+
+		download := func() {
+			if err := downloadFile(client, localMetaData, remoteMetaData, pool); err != nil { // download the remotefile and update
+				if IsBlockCorruption(err) {
+					fmt.Println("Could not download file, no replica has a good copy of a block: ", err)
+				} else {
+					fmt.Println("Could not download file: ", err)
+				}
 			}
 		}
+		if isTombstone(remoteMetaData.BlockHashList) {
+			download()
+			continue
+		}
+		if depth := strings.Count(fileName, "/"); depth != lastDepth {
+			wg.Wait()
+			lastDepth = depth
+		}
+		fileSlots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-fileSlots }()
+			download()
+		}()
 	}
+	wg.Wait()
 
 	// for fileName, localMetaData := range localIndex { // check local side
 	// 	if remoteMetaData, ok := remoteIndex[fileName]; ok {
@@ -139,123 +228,845 @@ func ClientSync(client RPCClient) {
 	// 	}
 	// }
 
+	if stats.uploadedBytes > 0 || stats.skippedBytes > 0 {
+		fmt.Printf("Uploaded %d bytes of blocks, skipped %d bytes the servers already had\n", stats.uploadedBytes, stats.skippedBytes)
+	}
+	if stats.copies > 0 {
+		fmt.Printf("Sent %d block copies to the replicas, %d bytes on the wire\n", stats.copies, stats.sentBytes)
+	}
+	repairReplicas(client, missing)
+
 	WriteMetaFile(localIndex, client.BaseDir)
+	if err := WriteRemoteIndex(remoteIndex, cursor, epoch, client.BaseDir); err != nil {
+		fmt.Println("Could not write remote index to meta file: ", err)
+	}
+	if err := WriteUnderReplicated(missing.missing, client.BaseDir); err != nil {
+		fmt.Println("Could not write under-replicated blocks to meta file: ", err)
+	}
 }
 
-func uploadFile(client RPCClient, metaData *FileMetaData, blockStoreAddrs []string, blockStoreMap map[string][]string) error {
-	path := client.BaseDir + "/" + metaData.Filename // local file path
+// fetchRemoteChanges brings the remote index up to date with the changes after cursor, a page at a time,
+// and moves the cursor (and the metastore epoch it belongs to) past them
+func fetchRemoteChanges(client RPCClient, remoteIndex map[string]*FileMetaData, cursor *int64, epoch *string) error {
+	for {
+		var fileMetaDatas []*FileMetaData
+		var nextSeq int64
+		var more bool
+		nextEpoch := *epoch
+		err := client.GetChangesSince(*cursor, &nextEpoch, &fileMetaDatas, &nextSeq, &more)
+		switch status.Code(err) {
+		case codes.OK:
+		case codes.OutOfRange: // the metastore lost its state (or restarted with a new epoch), our copy is useless, start over
+			if *cursor == 0 && *epoch == "" {
+				return err
+			}
+			for fileName := range remoteIndex {
+				delete(remoteIndex, fileName)
+			}
+			*cursor = 0
+			*epoch = ""
+			continue
+		case codes.Unimplemented: // an older metastore, fall back to downloading the whole index
+			fileInfoMap := make(map[string]*FileMetaData)
+			if err := client.GetFileInfoMap(&fileInfoMap); err != nil {
+				return err
+			}
+			for fileName := range remoteIndex {
+				delete(remoteIndex, fileName)
+			}
+			for fileName, fileMetaData := range fileInfoMap {
+				remoteIndex[fileName] = fileMetaData
+			}
+			*cursor = 0
+			*epoch = ""
+			return nil
+		default:
+			return err
+		}
 
-	// special cheeck: for deleted file
-	var latestVersion int32
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		e := client.UpdateFile(metaData, &latestVersion)
-		if e != nil {
-			fmt.Println("Could not update file: %v", err)
-		} else {
-			metaData.Version = latestVersion
+		for _, fileMetaData := range fileMetaDatas {
+			remoteIndex[fileMetaData.Filename] = fileMetaData
 		}
+		*cursor = nextSeq
+		*epoch = nextEpoch
+		if !more {
+			return nil
+		}
+	}
+}
+
+func printUploadError(err error) {
+	if IsBlockCorruption(err) {
+		fmt.Println("Could not upload file, the blockstores refused blocks corrupted on the way: ", err)
+	} else {
+		fmt.Println("Could not upload file: ", err)
+	}
+}
+
+// uploadStats counts the (uncompressed) block bytes the uploads of a sync uploaded, and the ones they skipped because the
+// blockstores already had the block (or it was repeated in the same file), every block once however many replicas it has.
+// The replica fan-out is counted separately: every copy of a block sent to a replica, and the bytes that actually went
+// over the wire for them (compressed).
+type uploadStats struct {
+	mu            sync.Mutex
+	uploadedBytes int64
+	skippedBytes  int64
+	copies        int64
+	sentBytes     int64
+}
+
+func (stats *uploadStats) add(uploadedBytes int64, skippedBytes int64, copies int64, sentBytes int64) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.uploadedBytes += uploadedBytes
+	stats.skippedBytes += skippedBytes
+	stats.copies += copies
+	stats.sentBytes += sentBytes
+}
+
+func uploadFile(client RPCClient, metaData *FileMetaData, blockStoreAddrs []string, pool *TransferPool, stats *uploadStats, missing *underReplicated) error {
+	path, err := localPath(client.BaseDir, metaData.Filename) // local file path
+	if err != nil {
 		return err
 	}
 
-	file, err := os.Open(path)
+	// special cheeck: for deleted files, directories and empty files there are no blocks to upload
+	var latestVersion int32
+	if _, err := os.Stat(path); os.IsNotExist(err) || !hasBlocks(metaData.BlockHashList) {
+		if err := client.UpdateFile(metaData, &latestVersion); err != nil {
+			return err // the version stays ahead of the server's, the next sync tries again
+		}
+		metaData.Version = latestVersion
+		return nil
+	}
 
+	file, err := os.Open(path)
 	if err != nil {
-		fmt.Println("Error opening file: ", err)
+		return err
 	}
 	defer file.Close()
 
-	fileStat, err := os.Stat(path)
+	// a membership change between placing the blocks and UpdateFile gets the update refused (ERR_STALE_RING), the
+	// blocks may be missing on their new owners. They are placed again with the new ring, most of them are already there.
+	for attempt := 1; ; attempt++ {
+		// only now that there are blocks to upload do we need to know where they go
+		blockStoreMap := make(map[string][]string)
+		var ringVersion int64
+		if err := client.GetVersionedBlockStoreMap(metaData.BlockHashList, &blockStoreMap, &ringVersion); err != nil {
+			return err
+		}
+		if err := putFileBlocks(client, file, metaData.Filename, blockStoreMap, pool, stats, missing); err != nil {
+			return err
+		}
+
+		update := &FileMetaData{Filename: metaData.Filename, Version: metaData.Version, BlockHashList: metaData.BlockHashList, RingVersion: ringVersion}
+		err := client.UpdateFile(update, &latestVersion)
+		if IsStaleRing(err) && attempt < STALE_RING_ATTEMPTS {
+			continue
+		}
+		if err != nil {
+			return err // the version stays ahead of the server's, the next sync tries again
+		}
+		metaData.Version = latestVersion
+		return nil
+	}
+}
+
+// putFileBlocks puts every block of the file on the blockstores of blockStoreMap
+func putFileBlocks(client RPCClient, file *os.File, filename string, blockStoreMap map[string][]string, pool *TransferPool, stats *uploadStats, missing *underReplicated) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// split the file exactly like computeHashList did, so the blocks match the hash list
+	chunker, err := NewChunker(client.Chunking, file, client.BlockSize)
 	if err != nil {
-		fmt.Println("Error geting fileInfo: ", err)
+		return err
 	}
 
-	var numsBlocks int = int(math.Ceil(float64(fileStat.Size()) / float64(client.BlockSize)))
-	for i := 0; i < numsBlocks; i++ {
-		byteSlice := make([]byte, client.BlockSize) // build a byteSlice to contain []bytes in the block
-		len, err := file.Read(byteSlice)            // the lenth of each block, the last block may be less than client.BlockSize
+	// the blocks are sent in batches, every replica is asked which blocks of the batch it is missing
+	// and gets only those, over one PutBlocks stream
+	replicas := getBlockReplicas(blockStoreMap)
+	batch := make(map[string][]string) // blockstore : hashes of the blocks of the batch it stores
+	batchHashes := []string{}
+	batchBlocks := make(map[string]*Block) // hash : block
+	batchBytes := 0
+	for {
+		byteSlice, err := chunker.Next() // the last block may be less than client.BlockSize
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			fmt.Println("Error reading bytes from file in basedir: ", err)
+			return err
 		}
-		byteSlice = byteSlice[:len] // read []byte from each block
+		// the hash is over the (encrypted) uncompressed data, that's what the hash list has
+		byteSlice = sealBlock(client, byteSlice)
+		hash := GetBlockHashString(byteSlice)
+		if _, ok := batchBlocks[hash]; ok {
+			stats.add(0, int64(len(byteSlice)), 0, 0) // a repeated block within the file
+			continue
+		}
+		block, err := CompressBlock(byteSlice, client.Compression)
+		if err != nil {
+			return err
+		}
+		block.Hash = hash // so the blockstores refuse it if it gets corrupted on the way
 
-		block := Block{BlockData: byteSlice, BlockSize: int32(len)}
-		hash := GetBlockHashString(block.BlockData)
+		for _, responsibleSever := range replicas[hash] {
+			batch[responsibleSever] = append(batch[responsibleSever], hash)
+		}
+		batchHashes = append(batchHashes, hash)
+		batchBlocks[hash] = block
+		batchBytes += len(block.BlockData)
+		if batchBytes >= BLOCK_BATCH_BYTES {
+			if err := putBlockBatch(client, batch, batchHashes, batchBlocks, replicas, filename, pool, stats, missing); err != nil {
+				return err
+			}
+			batch = make(map[string][]string)
+			batchHashes = []string{}
+			batchBlocks = make(map[string]*Block)
+			batchBytes = 0
+		}
+	}
+	if len(batchHashes) > 0 {
+		if err := putBlockBatch(client, batch, batchHashes, batchBlocks, replicas, filename, pool, stats, missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		var responsibleSever string
-		for blockStoreAddr, blockHashes := range blockStoreMap {
-			for _, blockHash := range blockHashes {
-				if hash == blockHash {
-					responsibleSever = blockStoreAddr
-				}
+func downloadFile(client RPCClient, localMetaData *FileMetaData, remoteMetaData *FileMetaData, pool *TransferPool) error {
+	// the filename comes from the server, make sure it stays inside the base directory
+	path, err := localPath(client.BaseDir, remoteMetaData.Filename) // local file path
+	if err != nil {
+		return err
+	}
+
+	// check deleted file (or directory, its contents were deleted before it)
+	if isTombstone(remoteMetaData.BlockHashList) {
+		fmt.Println("deleted file")
+		if _, err := os.Lstat(path); err == nil { // already gone (or its parent became a file) otherwise
+			if err := os.Remove(path); err != nil {
+				fmt.Println("Could not remove file: ", err)
 			}
 		}
+		copyFileMetaData(localMetaData, remoteMetaData)
+		return nil
+	}
 
-		var succ bool
-		if err := client.PutBlock(&block, strings.ReplaceAll(responsibleSever, "blockstore", ""), &succ); err != nil {
-			fmt.Println("Could not put block: %v", err)
+	// a file that became a directory or the other way round, the old one has to go first
+	if fileInfo, err := os.Lstat(path); err == nil && fileInfo.IsDir() != isDirectory(remoteMetaData.BlockHashList) {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("could not replace %s: %v", remoteMetaData.Filename, err)
 		}
 	}
 
-	if err := client.UpdateFile(metaData, &latestVersion); err != nil {
-		fmt.Println("Could not update file: %v", err)
+	if isDirectory(remoteMetaData.BlockHashList) {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		copyFileMetaData(localMetaData, remoteMetaData)
+		return nil
 	}
 
-	metaData.Version = latestVersion
+	// the parent directories may not have been synced yet (e.g. the file came from an older client)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// the new content goes to a temp file in DOWNLOAD_TMP_DIR, which replaces the user's file only once it is
+	// complete, so a failed download leaves the old file as it was. A temp file left behind by a crash is
+	// removed by the next sync.
+	tmpDir := ConcatPath(client.BaseDir, DOWNLOAD_TMP_DIR)
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(tmpDir, "*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	defer func() {
+		file.Close()
+		os.Remove(tmpPath) // already gone once it was renamed
+	}()
+
+	if hasBlocks(remoteMetaData.BlockHashList) { // otherwise an empty file
+		if err := writeBlocks(client, file, remoteMetaData, pool); err != nil {
+			return err
+		}
+	}
+
+	// keep the permissions of the file we replace, CreateTemp makes it readable by the owner only
+	mode := fs.FileMode(0644)
+	if fileInfo, err := os.Stat(path); err == nil {
+		mode = fileInfo.Mode().Perm()
+	}
+	if err := file.Chmod(mode); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("could not sync %s: %v", remoteMetaData.Filename, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("could not replace %s: %v", remoteMetaData.Filename, err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	copyFileMetaData(localMetaData, remoteMetaData)
 	return nil
 }
 
-func downloadFile(client RPCClient, localMetaData *FileMetaData, remoteMetaData *FileMetaData, blockStoreMap map[string][]string) error {
-	path := client.BaseDir + "/" + remoteMetaData.Filename // local file path
-
-	file, err := os.Create(path) // create new file regardless of whether it exists
+// writeBlocks downloads the blocks of the file into file, every one of them checked against the hash list
+func writeBlocks(client RPCClient, file *os.File, remoteMetaData *FileMetaData, pool *TransferPool) error {
+	m := make(map[string][]string)
+	err := client.GetBlockStoreMap(remoteMetaData.BlockHashList, &m) //return了一个blockStoreAdrr的map
 	if err != nil {
-		fmt.Println("Error creating file: ", err)
+		fmt.Println("Could not get blockStoreAddr: ", err)
 	}
 
-	defer file.Close()
+	// fetch the blocks a batch of about BLOCK_BATCH_BYTES at a time, every block goes to disk as soon as the blocks
+	// before it are there, so memory use is bounded by the batch size however large the file is. The size of a block
+	// is only known once it arrives, so every block counts as large as the largest block seen so far, and at least as
+	// large as the largest block our chunker makes (the file may have been chunked by another client).
+	replicas := getBlockReplicas(m)
+	hashList := remoteMetaData.BlockHashList // remote端的file的hash
+	writer := newBlockWriter(file, hashList)
+	writer.largest = maxBlockSize(client.Chunking, client.BlockSize)
+	for start := 0; start < len(hashList); {
+		end := start
+		for batchBytes := 0; end < len(hashList) && (end == start || batchBytes+writer.largest <= BLOCK_BATCH_BYTES); end++ {
+			batchBytes += writer.largest
+		}
+		writer.expect(end)
+		if err := getBlockBatch(client, hashList[start:end], replicas, remoteMetaData.Filename, pool, writer.put); err != nil {
+			return err
+		}
+		if err := writer.err; err != nil {
+			return fmt.Errorf("could not write %s: %v", remoteMetaData.Filename, err)
+		}
+		start = end
+	}
+	if writer.next != len(hashList) {
+		return fmt.Errorf("only %d of the %d blocks of %s were written", writer.next, len(hashList), remoteMetaData.Filename)
+	}
+	return nil
+}
+
+// saveConflictedCopy moves the local version of a conflicted file out of the way and uploads it as a new file,
+// so the server's version can be downloaded under the original name without losing the local edits
+func saveConflictedCopy(client RPCClient, localIndex map[string]*FileMetaData, fileName string, blockStoreAddrs []string, pool *TransferPool, stats *uploadStats, missing *underReplicated) {
+	localMetaData := localIndex[fileName]
+	// the file is downloaded again like one we never had
+	delete(localIndex, fileName)
+
+	// a deleted file or a directory has no content to lose, the server's version simply wins
+	if isTombstone(localMetaData.BlockHashList) || isDirectory(localMetaData.BlockHashList) {
+		fmt.Println("Conflict:", fileName, "was changed on the server, taking the server's version")
+		return
+	}
 
-	_, err = os.Stat(path)
+	hostname, err := os.Hostname()
 	if err != nil {
-		fmt.Println("Error geting fileInfo: ", err)
+		hostname = "unknown"
 	}
+	conflictName := conflictedCopyName(fileName, hostname, time.Now(), localIndex)
 
-	// check deleted file
-	if len(remoteMetaData.BlockHashList) == 1 && remoteMetaData.BlockHashList[0] == "0" {
-		fmt.Println("deleted file")
-		if err := os.Remove(path); err != nil {
-			fmt.Println("Could not remove file: %v", err)
+	oldPath, err := localPath(client.BaseDir, fileName)
+	if err != nil {
+		fmt.Println("Could not save conflicted copy: ", err)
+		return
+	}
+	newPath, err := localPath(client.BaseDir, conflictName)
+	if err != nil {
+		fmt.Println("Could not save conflicted copy: ", err)
+		return
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		fmt.Println("Could not save conflicted copy: ", err)
+		return
+	}
+
+	conflictMetaData := &FileMetaData{Filename: conflictName, Version: 1, BlockHashList: localMetaData.BlockHashList}
+	localIndex[conflictName] = conflictMetaData
+	if err := uploadFile(client, conflictMetaData, blockStoreAddrs, pool, stats, missing); err != nil {
+		fmt.Println("Could not upload file: ", err)
+	}
+	fmt.Println("Conflict:", fileName, "was changed on the server, your version was saved as", conflictName)
+}
+
+// conflictedCopyName returns "name (conflicted copy from <host> <date>).ext" in the same directory as fileName,
+// with a counter added if that name is already taken
+func conflictedCopyName(fileName string, hostname string, now time.Time, localIndex map[string]*FileMetaData) string {
+	dir, base := path.Split(fileName)
+	ext := path.Ext(base)
+	if ext == base { // dotfiles like .bashrc have no extension
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+	// no ':' in the time, it is not allowed in filenames everywhere, and no ',' which the client does not sync
+	label := "conflicted copy from " + strings.ReplaceAll(hostname, ",", "_") + " " + now.Format("2006-01-02 15-04-05")
+
+	conflictName := dir + stem + " (" + label + ")" + ext
+	for i := 2; localIndex[conflictName] != nil; i++ {
+		conflictName = dir + stem + " (" + label + " " + strconv.Itoa(i) + ")" + ext
+	}
+	return conflictName
+}
+
+// scanBaseDir walks the whole base directory and returns the hash list of every file and directory in it,
+// keyed by the path relative to the base directory with '/' as the separator
+func scanBaseDir(client RPCClient) (map[string][]string, error) {
+	hashMap := make(map[string][]string)
+	err := scanTree(client, client.BaseDir, hashMap)
+	return hashMap, err
+}
+
+// scanChangedPaths takes the hash lists of the unchanged files from the local index and only rescans changedPaths,
+// a changed directory is rescanned with everything under it
+func scanChangedPaths(client RPCClient, localIndex map[string]*FileMetaData, changedPaths []string) (map[string][]string, error) {
+	hashMap := make(map[string][]string)
+	for fileName, fileMetaData := range localIndex {
+		if !isTombstone(fileMetaData.BlockHashList) {
+			hashMap[fileName] = fileMetaData.BlockHashList
+		}
+	}
+
+	for _, changedPath := range changedPaths {
+		for fileName := range hashMap {
+			if fileName == changedPath || strings.HasPrefix(fileName, changedPath+"/") {
+				delete(hashMap, fileName)
+			}
+		}
+		root, err := localPath(client.BaseDir, changedPath)
+		if err != nil {
+			return hashMap, err
+		}
+		if _, err := os.Lstat(root); err != nil { // deleted, the tombstone is added like for a full scan
+			continue
+		}
+		if err := scanTree(client, root, hashMap); err != nil {
+			return hashMap, err
+		}
+	}
+	return hashMap, nil
+}
+
+// scanTree adds the hash list of root and everything under it to hashMap,
+// keyed by the path relative to the base directory with '/' as the separator
+func scanTree(client RPCClient, root string, hashMap map[string][]string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) { // removed while we were scanning
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(client.BaseDir, path)
+		if err != nil {
+			return err
+		}
+		fileName := filepath.ToSlash(relPath)
+
+		// check filename
+		if fileName == "." || isMetaFile(fileName) || strings.Contains(fileName, ",") {
+			if fileName != "." && d.IsDir() { // e.g. DOWNLOAD_TMP_DIR
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		*localMetaData = *remoteMetaData
+
+		if d.IsDir() {
+			hashMap[fileName] = []string{DIRECTORY_HASHVALUE}
+			return nil
+		}
+		if !d.Type().IsRegular() { // symlinks, sockets and so on are not synced
+			return nil
+		}
+
+		hashList, err := computeHashList(client, path)
+		if err != nil {
+			fmt.Println("Error reading file in basedir: ", err)
+			return nil
+		}
+		hashMap[fileName] = hashList
 		return nil
+	})
+}
+
+// the local index, the files sqlite keeps next to it (e.g. index.db-journal) and DOWNLOAD_TMP_DIR with the
+// downloads in progress are never synced
+func isMetaFile(fileName string) bool {
+	return fileName == DEFAULT_META_FILENAME || strings.HasPrefix(fileName, DEFAULT_META_FILENAME+"-")
+}
+
+// computeHashList splits the file into blocks with the client's chunking and returns their hashes, an empty file gets EMPTYFILE_HASHVALUE
+func computeHashList(client RPCClient, path string) ([]string, error) {
+	fileToRead, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer fileToRead.Close()
 
-	m := make(map[string][]string)
-	err = client.GetBlockStoreMap(remoteMetaData.BlockHashList, &m) //return了一个blockStoreAdrr的map
+	chunker, err := NewChunker(client.Chunking, fileToRead, client.BlockSize)
 	if err != nil {
-		fmt.Println("Could not get blockStoreAddr: ", err)
+		return nil, err
+	}
+	var hashlist []string
+	for {
+		byteSlice, err := chunker.Next() // the last block may be less than client.BlockSize
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		hashlist = append(hashlist, GetBlockHashString(sealBlock(client, byteSlice))) // compute each block's hash
 	}
+	if len(hashlist) == 0 {
+		return []string{EMPTYFILE_HASHVALUE}, nil
+	}
+	return hashlist, nil
+}
 
-	blockData := ""
-	for _, hash := range remoteMetaData.BlockHashList { // remote端的file的hash
-		for blockStoreAddr, blockHashes := range m {
-			for _, blockHash := range blockHashes {
+// localPath turns a filename from the index into a path under the base directory,
+// rejecting names that would escape it
+func localPath(baseDir string, fileName string) (string, error) {
+	cleaned := path.Clean(fileName)
+	if fileName == "" || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file name %q", fileName)
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(cleaned)), nil
+}
 
-				if hash == blockHash {
-					fmt.Println("hash==blockhash")
-					var block Block
-					if err := client.GetBlock(hash, strings.ReplaceAll(blockStoreAddr, "blockstore", ""), &block); err != nil {
-						fmt.Println("Could not get block: %v", err)
+// downloadOrder sorts the files of the remote index: deletions deepest first, then the rest shallowest first
+func downloadOrder(remoteIndex map[string]*FileMetaData) []string {
+	fileNames := make([]string, 0, len(remoteIndex))
+	for fileName := range remoteIndex {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Slice(fileNames, func(i, j int) bool {
+		deletedI, deletedJ := isTombstone(remoteIndex[fileNames[i]].BlockHashList), isTombstone(remoteIndex[fileNames[j]].BlockHashList)
+		if deletedI != deletedJ {
+			return deletedI
+		}
+		depthI, depthJ := strings.Count(fileNames[i], "/"), strings.Count(fileNames[j], "/")
+		if depthI != depthJ {
+			if deletedI {
+				return depthI > depthJ
+			}
+			return depthI < depthJ
+		}
+		return fileNames[i] < fileNames[j]
+	})
+	return fileNames
+}
+
+func isTombstone(hashList []string) bool {
+	return len(hashList) == 1 && hashList[0] == TOMBSTONE_HASHVALUE
+}
+
+func isDirectory(hashList []string) bool {
+	return len(hashList) == 1 && hashList[0] == DIRECTORY_HASHVALUE
+}
+
+// whether the hash list refers to real blocks, i.e. it is not a deleted file, a directory or an empty file
+func hasBlocks(hashList []string) bool {
+	if len(hashList) == 0 {
+		return false
+	}
+	if len(hashList) == 1 {
+		switch hashList[0] {
+		case TOMBSTONE_HASHVALUE, EMPTYFILE_HASHVALUE, DIRECTORY_HASHVALUE:
+			return false
+		}
+	}
+	return true
+}
+
+// sealBlock encrypts the data of a block if the client encrypts, the block hash is the hash of what it returns
+func sealBlock(client RPCClient, data []byte) []byte {
+	if client.Crypter == nil {
+		return data
+	}
+	return client.Crypter.SealBlock(data)
+}
+
+// openBlock decrypts and authenticates a block sealed by sealBlock
+func openBlock(client RPCClient, data []byte) ([]byte, error) {
+	if client.Crypter == nil {
+		return data, nil
+	}
+	return client.Crypter.OpenBlock(data)
+}
+
+// getBlockReplicas turns the blockstore map (server : hashes) into hash : every server holding a replica of it
+func getBlockReplicas(blockStoreMap map[string][]string) map[string][]string {
+	replicas := make(map[string][]string)
+	for blockStoreAddr, blockHashes := range blockStoreMap {
+		for _, blockHash := range blockHashes {
+			// a hash repeated in the request is listed again, a block is still sent to each replica once
+			if !containsString(replicas[blockHash], blockStoreAddr) {
+				replicas[blockHash] = append(replicas[blockHash], blockStoreAddr)
+			}
+		}
+	}
+	return replicas
+}
+
+// putBlockBatch asks every blockstore which of its blocks of the batch it already has and sends it the others, the
+// share of every blockstore split over up to pool.PerServer streams that run in parallel. Then it checks that every
+// block of the batch reached a write quorum of its replicas, and records the replicas it did not reach in missing.
+func putBlockBatch(client RPCClient, batch map[string][]string, hashes []string, blocks map[string]*Block, replicas map[string][]string, fileName string, pool *TransferPool, stats *uploadStats, missing *underReplicated) error {
+	stored := make(map[string][]string) // hash : replicas that have it
+	sent := make(map[string]int)        // hash : replicas it was sent to
+	var dataLoss error                  // the last PutBlocks a blockstore refused because a block was corrupted
+	var mu sync.Mutex                   // guards stored, sent and dataLoss
+	var wg sync.WaitGroup
+	for responsibleSever, serverHashes := range batch {
+		blockStoreAddr := strings.ReplaceAll(responsibleSever, "blockstore", "")
+		for _, part := range split(serverHashes, pool.PerServer) {
+			pool.Go(&wg, blockStoreAddr, func() {
+				var present []string
+				if err := client.HasBlocks(part, blockStoreAddr, &present); err != nil {
+					fmt.Println("Could not check blocks: ", err) // just send them all
+				}
+				has := make(map[string]bool)
+				for _, hash := range present {
+					has[hash] = true
+				}
+
+				missing := []*Block{}
+				var storedHashes, sentHashes []string
+				for _, hash := range part {
+					if has[hash] {
+						storedHashes = append(storedHashes, hash)
+					} else {
+						missing = append(missing, blocks[hash])
 					}
-					blockData += string(block.BlockData)
 				}
+				var sentBytes int64
+				var putErr error
+				if len(missing) > 0 {
+					if putErr = client.PutBlocks(missing, blockStoreAddr, &sentHashes); putErr != nil {
+						fmt.Println("Could not put blocks: ", putErr)
+					}
+					// the blocks it did store before an error still count
+					storedHashes = append(storedHashes, sentHashes...)
+					for _, hash := range sentHashes {
+						sentBytes += int64(len(blocks[hash].BlockData))
+					}
+				}
+
+				stats.add(0, 0, int64(len(sentHashes)), sentBytes)
+				mu.Lock()
+				for _, hash := range storedHashes {
+					stored[hash] = append(stored[hash], responsibleSever)
+				}
+				for _, hash := range sentHashes {
+					sent[hash]++
+				}
+				if IsBlockCorruption(putErr) {
+					dataLoss = putErr
+				}
+				mu.Unlock()
+			})
+		}
+	}
+	wg.Wait()
+
+	for _, hash := range hashes {
+		if len(stored[hash]) < writeQuorum(len(replicas[hash])) {
+			if dataLoss != nil {
+				return fmt.Errorf("block %s of %s reached only %d of %d replicas: %w", hash, fileName, len(stored[hash]), len(replicas[hash]), dataLoss)
 			}
+			return fmt.Errorf("block %s of %s reached only %d of %d replicas", hash, fileName, len(stored[hash]), len(replicas[hash]))
 		}
 	}
-	//fmt.Println(blockData)
-	if _, err := file.WriteString(blockData); err != nil {
-		fmt.Println("Could not write to file: %v", err)
+	var uploadedBytes, skippedBytes int64
+	for _, hash := range hashes {
+		for _, responsibleSever := range replicas[hash] {
+			if !containsString(stored[hash], responsibleSever) {
+				missing.add(hash, responsibleSever)
+			}
+		}
+		// uploaded if any replica needed it, however many did
+		if sent[hash] > 0 {
+			uploadedBytes += int64(blocks[hash].BlockSize)
+		} else {
+			skippedBytes += int64(blocks[hash].BlockSize)
+		}
 	}
-
-	*localMetaData = *remoteMetaData
+	stats.add(uploadedBytes, skippedBytes, 0, 0)
 	return nil
 }
+
+// getBlockBatch fetches the blocks with the given hashes and hands the (decrypted) data of each one to deliver,
+// once per hash, in whatever order they arrive. Every block is asked from its first replica, and what is still
+// missing (or came back corrupted) from the next one. A block deliver can't take yet (it returns false) is asked
+// again from the same replica. The blocks asked from one blockstore are split over up to pool.PerServer GetBlocks
+// streams, and all of them run in parallel.
+func getBlockBatch(client RPCClient, hashes []string, replicas map[string][]string, fileName string, pool *TransferPool, deliver func(hash string, data []byte) bool) error {
+	got := make(map[string]bool)
+	failed := make(map[string]int)                        // hash : how many of its replicas could not give it
+	corruptions := make(map[string]*BlockCorruptionError) // hash : the last corrupted copy of it we got
+	var mu sync.Mutex                                     // guards got, failed and corruptions
+	for {
+		wanted := make(map[string][]string) // blockstore : hashes to ask it for
+		asked := make(map[string]bool)
+		for _, hash := range hashes {
+			if got[hash] || asked[hash] {
+				continue
+			}
+			if failed[hash] >= len(replicas[hash]) {
+				if corruptions[hash] != nil {
+					return fmt.Errorf("could not get block %s of %s from any replica: %w", hash, fileName, corruptions[hash])
+				}
+				return fmt.Errorf("could not get block %s of %s from any replica", hash, fileName)
+			}
+			blockStoreAddr := replicas[hash][failed[hash]]
+			wanted[blockStoreAddr] = append(wanted[blockStoreAddr], hash)
+			asked[hash] = true
+		}
+		if len(wanted) == 0 {
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		for blockStoreAddr, blockHashes := range wanted {
+			blockStoreAddr = strings.ReplaceAll(blockStoreAddr, "blockstore", "")
+			for _, part := range split(blockHashes, pool.PerServer) {
+				pool.Go(&wg, blockStoreAddr, func() {
+					var blocks []*Block
+					if err := client.GetBlocks(part, blockStoreAddr, &blocks); err != nil {
+						fmt.Println("Could not get blocks: ", err)
+					}
+					// the blocks received before an error are still good
+					delivered := make(map[string]bool)
+					deferred := make(map[string]bool)
+					for i, block := range blocks {
+						// the hash list is what we trust, a block that doesn't match it is fetched from another replica
+						blockData, err := VerifyBlock(part[i], block)
+						if corruption, ok := err.(*BlockCorruptionError); ok {
+							corruption.Source = blockStoreAddr
+							fmt.Println("Corrupted block: ", err)
+							mu.Lock()
+							corruptions[part[i]] = corruption
+							mu.Unlock()
+							continue
+						}
+						if err != nil {
+							fmt.Println("Could not decompress block: ", err)
+							continue
+						}
+						if blockData, err = openBlock(client, blockData); err != nil {
+							fmt.Println("Could not get block: ", err)
+							continue
+						}
+						if deliver(part[i], blockData) {
+							delivered[part[i]] = true
+						} else {
+							deferred[part[i]] = true
+						}
+					}
+					mu.Lock()
+					for _, hash := range part {
+						if delivered[hash] {
+							got[hash] = true
+						} else if !deferred[hash] {
+							failed[hash]++
+						}
+					}
+					mu.Unlock()
+				})
+			}
+		}
+		wg.Wait()
+	}
+}
+
+// blockWriter writes the blocks of a file in the order of its hash list while they arrive in any order:
+// a block is written as soon as every block before it is, only the blocks that arrived early wait in memory,
+// at most EARLY_BLOCK_BYTES of them. A block that doesn't fit is turned away and has to be fetched again.
+type blockWriter struct {
+	mu         sync.Mutex
+	file       *os.File
+	hashList   []string
+	next       int               // index in hashList of the next block to write
+	end        int               // blocks before this index are being fetched
+	remaining  map[string]int    // hash : how often it is still to be written before end
+	early      map[string][]byte // hash : data of a block that arrived before its turn
+	earlyBytes int               // size of the blocks in early
+	largest    int               // size of the largest block seen
+	err        error             // the first write error, nothing is written after it
+}
+
+func newBlockWriter(file *os.File, hashList []string) *blockWriter {
+	return &blockWriter{
+		file:      file,
+		hashList:  hashList,
+		remaining: make(map[string]int),
+		early:     make(map[string][]byte),
+	}
+}
+
+// expect announces that the blocks up to end are fetched next, every hash of them is put once
+func (w *blockWriter) expect(end int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, hash := range w.hashList[w.end:end] {
+		w.remaining[hash]++
+	}
+	w.end = end
+}
+
+// put takes the data of a block, false if it arrived early and there is no room to keep it. The next block to
+// write is always taken, so the file keeps growing however full the buffer is, and if it repeats later it stays
+// (the buffer can go over by the blocks of a batch that repeat, the batch is bounded by bytes too).
+func (w *blockWriter) put(hash string, data []byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(data) > w.largest {
+		w.largest = len(data)
+	}
+	if w.next < w.end && w.hashList[w.next] != hash && w.earlyBytes+len(data) > EARLY_BLOCK_BYTES {
+		return false
+	}
+	w.early[hash] = data
+	w.earlyBytes += len(data)
+	for w.next < w.end && w.err == nil {
+		nextHash := w.hashList[w.next]
+		data, ok := w.early[nextHash]
+		if !ok {
+			return true
+		}
+		if _, err := w.file.Write(data); err != nil {
+			w.err = err
+			return true
+		}
+		w.next++
+		// a block repeated later in the batch stays until its last occurrence is written
+		if w.remaining[nextHash]--; w.remaining[nextHash] == 0 {
+			delete(w.remaining, nextHash)
+			delete(w.early, nextHash)
+			w.earlyBytes -= len(data)
+		}
+	}
+	return true
+}
+
+// a write succeeds once a majority of the replicas stored the block
+func writeQuorum(replicas int) int {
+	return replicas/2 + 1
+}
+
+// copy the fields one by one, FileMetaData carries protobuf internal state which must not be copied
+func copyFileMetaData(dst *FileMetaData, src *FileMetaData) {
+	dst.Filename = src.Filename
+	dst.Version = src.Version
+	dst.BlockHashList = src.BlockHashList
+}
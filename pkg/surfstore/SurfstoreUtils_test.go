@@ -0,0 +1,68 @@
+package surfstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blocks that arrive in reverse order are kept until the buffer is full, the rest is turned away and fetched again,
+// and the file still comes out in hash list order
+func TestBlockWriterEarlyBytes(t *testing.T) {
+	const blockSize = 1024 * 1024
+	blocks := [][]byte{}
+	hashList := []string{}
+	want := []byte{}
+	for i := 0; i < 2*EARLY_BLOCK_BYTES/blockSize; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, blockSize)
+		blocks = append(blocks, data)
+		hashList = append(hashList, GetBlockHashString(data))
+		want = append(want, data...)
+	}
+	// a repeated block is fetched once and written twice
+	blocks = append(blocks, blocks[0])
+	hashList = append(hashList, hashList[0])
+	want = append(want, blocks[0]...)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := newBlockWriter(file, hashList)
+	writer.expect(len(hashList))
+
+	got := make(map[string]bool)
+	for rounds := 0; len(got) < len(hashList)-1; rounds++ {
+		if rounds > len(hashList) {
+			t.Fatalf("no progress after %d rounds, %d blocks written", rounds, writer.next)
+		}
+		for i := len(hashList) - 2; i >= 0; i-- {
+			if got[hashList[i]] {
+				continue
+			}
+			if writer.put(hashList[i], blocks[i]) {
+				got[hashList[i]] = true
+			}
+			// the first block was taken as the next one to write although the buffer was full, it repeats so it stays
+			if writer.earlyBytes > EARLY_BLOCK_BYTES+blockSize {
+				t.Fatalf("%d bytes of early blocks, the limit is %d", writer.earlyBytes, EARLY_BLOCK_BYTES+blockSize)
+			}
+		}
+	}
+
+	if writer.err != nil || writer.next != len(hashList) {
+		t.Fatalf("wrote %d of %d blocks: %v", writer.next, len(hashList), writer.err)
+	}
+	written, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, want) {
+		t.Errorf("the file does not match the blocks in hash list order")
+	}
+	if writer.earlyBytes != 0 || len(writer.early) != 0 {
+		t.Errorf("%d early blocks (%d bytes) left over", len(writer.early), writer.earlyBytes)
+	}
+}
@@ -0,0 +1,86 @@
+package surfstore
+
+import (
+	"sync"
+)
+
+/*
+client side:
+the transfer pool bounds how many block transfers (a HasBlocks + PutBlocks or a GetBlocks stream) run at the same time,
+at most Concurrency overall and at most PerServer against any one blockstore, so a sync keeps every responsible
+server busy without a single slow server taking all the slots. Tasks must not start other tasks in the same pool,
+they would wait for slots held by themselves.
+*/
+
+const DEFAULT_CONCURRENCY int = 8
+const DEFAULT_PER_SERVER_CONCURRENCY int = 4
+
+type TransferPool struct {
+	Concurrency int
+	PerServer   int
+
+	slots chan struct{} // one token per running transfer
+
+	mu          sync.Mutex
+	serverSlots map[string]chan struct{} // blockstore : one token per running transfer against it
+}
+
+// NewTransferPool returns a pool running at most concurrency transfers at once, and at most perServer against
+// one blockstore. Values <= 0 get the defaults.
+func NewTransferPool(concurrency int, perServer int) *TransferPool {
+	if concurrency <= 0 {
+		concurrency = DEFAULT_CONCURRENCY
+	}
+	if perServer <= 0 {
+		perServer = DEFAULT_PER_SERVER_CONCURRENCY
+	}
+	if perServer > concurrency {
+		perServer = concurrency
+	}
+	return &TransferPool{
+		Concurrency: concurrency,
+		PerServer:   perServer,
+		slots:       make(chan struct{}, concurrency),
+		serverSlots: make(map[string]chan struct{}),
+	}
+}
+
+func (p *TransferPool) serverSlot(server string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.serverSlots[server] == nil {
+		p.serverSlots[server] = make(chan struct{}, p.PerServer)
+	}
+	return p.serverSlots[server]
+}
+
+// Go waits until a transfer against server may start, then runs task in its own goroutine. wg is done when task returns.
+func (p *TransferPool) Go(wg *sync.WaitGroup, server string, task func()) {
+	// always the server slot first, then the overall one, so waiting for a busy server never holds an overall slot
+	serverSlot := p.serverSlot(server)
+	serverSlot <- struct{}{}
+	p.slots <- struct{}{}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			<-p.slots
+			<-serverSlot
+		}()
+		task()
+	}()
+}
+
+// split cuts list into at most n contiguous parts of about the same length, so one server's share of a batch
+// can be moved over several streams at once
+func split(list []string, n int) [][]string {
+	if n > len(list) {
+		n = len(list)
+	}
+	parts := [][]string{}
+	for i := 0; i < n; i++ {
+		parts = append(parts, list[i*len(list)/n:(i+1)*len(list)/n])
+	}
+	return parts
+}